@@ -1,6 +1,7 @@
 package muxer
 
 import (
+	"fmt"
 	"net/http"
 	"net/http/httptest"
 	"testing"
@@ -57,3 +58,54 @@ func BenchmarkManyPathVariables(b *testing.B) {
 		router.ServeHTTP(recorder, matchingRequest)
 	}
 }
+
+/*
+BenchmarkStaticRoute measures the lookup of a parameterless route among a growing
+number of registered routes. Static routes are indexed by method and path, so the
+time per lookup should stay roughly constant regardless of the route count.
+*/
+func BenchmarkStaticRoute(b *testing.B) {
+	handler := func(w http.ResponseWriter, r *http.Request) {}
+
+	for _, count := range []int{10, 100, 1000} {
+		router := &Router{}
+		for i := 0; i < count; i++ {
+			router.HandleRoute(http.MethodGet, fmt.Sprintf("/items/%d/:id", i), handler)
+			router.HandleRoute(http.MethodGet, fmt.Sprintf("/static/%d", i), handler)
+		}
+
+		req := httptest.NewRequest(http.MethodGet, fmt.Sprintf("/static/%d", count-1), nil)
+		w := httptest.NewRecorder()
+
+		b.Run(fmt.Sprintf("routes=%d", count*2), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				router.ServeHTTP(w, req)
+			}
+		})
+	}
+}
+
+/*
+BenchmarkParamRoute measures the lookup of a parameterized route registered last
+among a growing number of parameterized routes. Routes are indexed by path segment,
+so the time per lookup depends on the depth of the path rather than the route count.
+*/
+func BenchmarkParamRoute(b *testing.B) {
+	handler := func(w http.ResponseWriter, r *http.Request) {}
+
+	for _, count := range []int{10, 100, 1000} {
+		router := &Router{}
+		for i := 0; i < count; i++ {
+			router.HandleRoute(http.MethodGet, fmt.Sprintf("/items/%d/:id", i), handler)
+		}
+
+		req := httptest.NewRequest(http.MethodGet, fmt.Sprintf("/items/%d/42", count-1), nil)
+		w := httptest.NewRecorder()
+
+		b.Run(fmt.Sprintf("routes=%d", count), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				router.ServeHTTP(w, req)
+			}
+		})
+	}
+}
@@ -0,0 +1,148 @@
+package muxer
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"reflect"
+	"strconv"
+	"sync"
+)
+
+// paramTypes holds the coercions registered with RegisterParamType.
+var (
+	paramTypesMu sync.RWMutex
+	paramTypes   = make(map[reflect.Type]func(string) (interface{}, error))
+)
+
+/*
+RegisterParamType registers a coercion used by BindParams to bind parameters to
+fields of type typ, such as a UUID or a custom ID type. The value returned by
+coerce must be assignable to typ. Registered coercions take precedence over the
+built-in conversions, and registering typ again replaces its coercion. It is safe
+to call concurrently, but is typically called from init.
+
+	Example usage:
+	  muxer.RegisterParamType(reflect.TypeOf(uuid.UUID{}), func(s string) (interface{}, error) {
+	      return uuid.Parse(s)
+	  })
+*/
+func RegisterParamType(typ reflect.Type, coerce func(string) (interface{}, error)) {
+	paramTypesMu.Lock()
+	defer paramTypesMu.Unlock()
+
+	paramTypes[typ] = coerce
+}
+
+/*
+BindParams populates the fields of the struct pointed to by dst from the path
+parameters of the request. Fields are matched by their `param` struct tag and the
+parameter values are converted to the field's type. Supported field types are
+string, bool, the signed, unsigned and floating point numeric types, and types
+registered with RegisterParamType.
+
+Fields without a `param` tag, and fields whose parameter is not present in the
+request, are left untouched. An error naming the field and parameter is returned
+if a value cannot be converted.
+
+	Example usage:
+	  type postParams struct {
+	      UserID int    `param:"id"`
+	      Slug   string `param:"slug"`
+	  }
+
+	  router.HandleRoute("GET", "/users/:id/posts/:slug", func(w http.ResponseWriter, r *http.Request) {
+	      var p postParams
+	      if err := muxer.BindParams(r, &p); err != nil {
+	          http.Error(w, err.Error(), http.StatusBadRequest)
+	          return
+	      }
+	      // ...
+	  })
+*/
+func BindParams(req *http.Request, dst interface{}) error {
+	rv := reflect.ValueOf(dst)
+	if rv.Kind() != reflect.Ptr || rv.IsNil() || rv.Elem().Kind() != reflect.Struct {
+		return errors.New("destination must be a non-nil pointer to a struct")
+	}
+
+	params := Params(req)
+	rv = rv.Elem()
+	rt := rv.Type()
+
+	for i := 0; i < rt.NumField(); i++ {
+		field := rt.Field(i)
+		name, ok := field.Tag.Lookup("param")
+		if !ok || name == "" || name == "-" {
+			continue
+		}
+
+		value, ok := params[name]
+		if !ok {
+			continue
+		}
+
+		fv := rv.Field(i)
+		if !fv.CanSet() {
+			return fmt.Errorf("cannot bind param %q to unexported field %s", name, field.Name)
+		}
+
+		if err := setField(fv, value); err != nil {
+			return fmt.Errorf("cannot bind param %q to field %s: %w", name, field.Name, err)
+		}
+	}
+
+	return nil
+}
+
+// setField converts value to the type of field and stores it.
+func setField(field reflect.Value, value string) error {
+	paramTypesMu.RLock()
+	coerce := paramTypes[field.Type()]
+	paramTypesMu.RUnlock()
+
+	if coerce != nil {
+		v, err := coerce(value)
+		if err != nil {
+			return err
+		}
+		rv := reflect.ValueOf(v)
+		if !rv.IsValid() || !rv.Type().AssignableTo(field.Type()) {
+			return fmt.Errorf("coercion for %s returned %T", field.Type(), v)
+		}
+		field.Set(rv)
+		return nil
+	}
+
+	switch field.Kind() {
+	case reflect.String:
+		field.SetString(value)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(value)
+		if err != nil {
+			return err
+		}
+		field.SetBool(b)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		n, err := strconv.ParseInt(value, 10, field.Type().Bits())
+		if err != nil {
+			return err
+		}
+		field.SetInt(n)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		n, err := strconv.ParseUint(value, 10, field.Type().Bits())
+		if err != nil {
+			return err
+		}
+		field.SetUint(n)
+	case reflect.Float32, reflect.Float64:
+		f, err := strconv.ParseFloat(value, field.Type().Bits())
+		if err != nil {
+			return err
+		}
+		field.SetFloat(f)
+	default:
+		return fmt.Errorf("unsupported field type %s", field.Type())
+	}
+	return nil
+}
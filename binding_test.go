@@ -0,0 +1,125 @@
+package muxer
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func TestBindParams(t *testing.T) {
+	type postParams struct {
+		UserID int    `param:"id"`
+		Slug   string `param:"slug"`
+		Ignore string
+	}
+
+	router := NewRouter()
+
+	var (
+		bound   postParams
+		bindErr error
+	)
+	router.HandleRoute(http.MethodGet, "/users/:id/posts/:slug", func(w http.ResponseWriter, r *http.Request) {
+		bound = postParams{}
+		bindErr = BindParams(r, &bound)
+	})
+
+	tests := []struct {
+		name          string
+		path          string
+		expected      postParams
+		expectedError string
+	}{
+		{
+			name:     "int and string fields",
+			path:     "/users/42/posts/hello-world",
+			expected: postParams{UserID: 42, Slug: "hello-world"},
+		},
+		{
+			name:          "conversion failure names field and param",
+			path:          "/users/abc/posts/hello-world",
+			expectedError: `cannot bind param "id" to field UserID`,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, tc.path, nil))
+
+			if tc.expectedError != "" {
+				if bindErr == nil || !strings.Contains(bindErr.Error(), tc.expectedError) {
+					t.Fatalf("expected error containing %q, got %v", tc.expectedError, bindErr)
+				}
+				return
+			}
+			if bindErr != nil {
+				t.Fatalf("unexpected error: %v", bindErr)
+			}
+			if bound != tc.expected {
+				t.Errorf("expected %+v, got %+v", tc.expected, bound)
+			}
+		})
+	}
+}
+
+func TestBindParams_InvalidDestination(t *testing.T) {
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+
+	var notStruct int
+	for _, dst := range []interface{}{nil, notStruct, &notStruct} {
+		if err := BindParams(req, dst); err == nil {
+			t.Errorf("expected error for destination %T", dst)
+		}
+	}
+}
+
+type orderID struct {
+	prefix string
+	number int
+}
+
+func TestRegisterParamType(t *testing.T) {
+	RegisterParamType(reflect.TypeOf(orderID{}), func(s string) (interface{}, error) {
+		prefix, number, ok := strings.Cut(s, "-")
+		if !ok {
+			return nil, errors.New("malformed order id")
+		}
+		n, err := strconv.Atoi(number)
+		if err != nil {
+			return nil, err
+		}
+		return orderID{prefix: prefix, number: n}, nil
+	})
+
+	type orderParams struct {
+		ID orderID `param:"id"`
+	}
+
+	router := NewRouter()
+
+	var (
+		bound   orderParams
+		bindErr error
+	)
+	router.HandleRoute(http.MethodGet, "/orders/:id", func(w http.ResponseWriter, r *http.Request) {
+		bound = orderParams{}
+		bindErr = BindParams(r, &bound)
+	})
+
+	router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/orders/EU-1042", nil))
+	if bindErr != nil {
+		t.Fatalf("unexpected error: %v", bindErr)
+	}
+	if expected := (orderID{prefix: "EU", number: 1042}); bound.ID != expected {
+		t.Errorf("unexpected binding: expected=%+v, actual=%+v", expected, bound.ID)
+	}
+
+	router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/orders/1042", nil))
+	if bindErr == nil || !strings.Contains(bindErr.Error(), "malformed order id") {
+		t.Errorf("unexpected error: %v", bindErr)
+	}
+}
@@ -0,0 +1,107 @@
+package muxer
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"sync/atomic"
+)
+
+// bodyBudgetKey is the context key under which the request's *bodyBudget is stored.
+const bodyBudgetKey contextKey = "body_budget"
+
+// bodyBudget tracks the body size limit of a request and the bytes read against it.
+type bodyBudget struct {
+	read  int64 // accessed atomically
+	limit int64
+}
+
+// errBodyTooLarge is returned by a request body read past the router's body size
+// limit. Its message matches the error of http.MaxBytesReader.
+var errBodyTooLarge = errors.New("http: request body too large")
+
+// countingBody wraps a request body limited with http.MaxBytesReader and records
+// the bytes read in its budget.
+type countingBody struct {
+	io.ReadCloser
+	budget *bodyBudget
+}
+
+func (b *countingBody) Read(p []byte) (int, error) {
+	n, err := b.ReadCloser.Read(p)
+	read := atomic.AddInt64(&b.budget.read, int64(n))
+	// MaxBytesReader fails once the limit is used up and more of the body remains
+	if err != nil && err != io.EOF && read >= b.budget.limit {
+		err = errBodyTooLarge
+	}
+	return n, err
+}
+
+/*
+BodyBudget returns the request body size limit enforced by the router (see
+WithMaxRequestBodySize) and the number of body bytes read so far. Streaming
+handlers can use the difference to decide how much more they can consume before
+the read fails with "http: request body too large". If the router has no body
+size limit, both values are zero.
+
+	Example usage:
+	  limit, read := muxer.BodyBudget(r)
+	  if limit > 0 && limit-read < chunkSize {
+	      // not enough budget left for another chunk
+	  }
+*/
+func BodyBudget(req *http.Request) (limit, read int64) {
+	budget, ok := req.Context().Value(bodyBudgetKey).(*bodyBudget)
+	if !ok {
+		return 0, 0
+	}
+	return budget.limit, atomic.LoadInt64(&budget.read)
+}
+
+/*
+EachUploadedFile streams the files of a multipart/form-data request body to fn one
+at a time, in the order they were sent, without buffering them in memory or on disk
+as ParseMultipartForm does. Parts that are not files are skipped. file must be
+consumed before fn returns; it is an io.Reader rather than a multipart.File
+because a stream cannot be sought. Reading is subject to the router's body size
+limit, see WithMaxRequestBodySize. EachUploadedFile stops at the first error
+returned by fn or encountered reading the body, and returns it; it returns
+http.ErrNotMultipart if the body is not multipart.
+
+	err := muxer.EachUploadedFile(r, func(filename string, file io.Reader) error {
+	    dst, err := os.Create(filepath.Join(uploadDir, filepath.Base(filename)))
+	    if err != nil {
+	        return err
+	    }
+	    defer dst.Close()
+	    _, err = io.Copy(dst, file)
+	    return err
+	})
+*/
+func EachUploadedFile(r *http.Request, fn func(filename string, file io.Reader) error) error {
+	reader, err := r.MultipartReader()
+	if err != nil {
+		return err
+	}
+
+	for {
+		part, err := reader.NextPart()
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		filename := part.FileName()
+		if filename == "" {
+			part.Close()
+			continue
+		}
+		err = fn(filename, part)
+		part.Close()
+		if err != nil {
+			return err
+		}
+	}
+}
@@ -0,0 +1,152 @@
+package muxer
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestBodyBudget(t *testing.T) {
+	router := NewRouter(WithMaxRequestBodySize(100))
+
+	var limit, before, after int64
+	router.HandleRoute(http.MethodPost, "/upload", func(w http.ResponseWriter, r *http.Request) {
+		limit, before = BodyBudget(r)
+		if _, err := io.ReadFull(r.Body, make([]byte, 30)); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		_, after = BodyBudget(r)
+	})
+
+	req := httptest.NewRequest(http.MethodPost, "/upload", strings.NewReader(strings.Repeat("a", 60)))
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("unexpected status code: expected=%d, actual=%d", http.StatusOK, w.Code)
+	}
+	if limit != 100 {
+		t.Errorf("unexpected limit: expected=%d, actual=%d", 100, limit)
+	}
+	if before != 0 {
+		t.Errorf("unexpected bytes read before reading: expected=%d, actual=%d", 0, before)
+	}
+	if after != 30 {
+		t.Errorf("unexpected bytes read mid-handler: expected=%d, actual=%d", 30, after)
+	}
+}
+
+func TestBodyBudget_NoLimit(t *testing.T) {
+	router := NewRouter()
+
+	var limit, read int64 = -1, -1
+	router.HandleRoute(http.MethodPost, "/upload", func(w http.ResponseWriter, r *http.Request) {
+		limit, read = BodyBudget(r)
+	})
+
+	router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodPost, "/upload", strings.NewReader("data")))
+
+	if limit != 0 || read != 0 {
+		t.Errorf("expected no budget without a limit, got limit=%d read=%d", limit, read)
+	}
+}
+
+// multipartBody returns a multipart/form-data body with a text field and the files,
+// and its content type.
+func multipartBody(t *testing.T, files map[string]string, order []string) (*bytes.Buffer, string) {
+	t.Helper()
+
+	body := &bytes.Buffer{}
+	mw := multipart.NewWriter(body)
+	if err := mw.WriteField("description", "holiday photos"); err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range order {
+		fw, err := mw.CreateFormFile("files", name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := io.WriteString(fw, files[name]); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := mw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return body, mw.FormDataContentType()
+}
+
+func TestEachUploadedFile(t *testing.T) {
+	files := map[string]string{"a.txt": "first file", "b.txt": strings.Repeat("b", 1000)}
+	order := []string{"a.txt", "b.txt"}
+
+	router := NewRouter(WithMaxRequestBodySize(4096))
+	var got []string
+	router.HandleRoute(http.MethodPost, "/upload", func(w http.ResponseWriter, r *http.Request) {
+		err := EachUploadedFile(r, func(filename string, file io.Reader) error {
+			content, err := io.ReadAll(file)
+			if err != nil {
+				return err
+			}
+			if string(content) != files[filename] {
+				t.Errorf("unexpected content of %s: %d bytes", filename, len(content))
+			}
+			got = append(got, filename)
+			return nil
+		})
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+		}
+	})
+
+	body, contentType := multipartBody(t, files, order)
+	req := httptest.NewRequest(http.MethodPost, "/upload", body)
+	req.Header.Set("Content-Type", contentType)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("unexpected status code: expected=%d, actual=%d (%s)", http.StatusOK, w.Code, w.Body.String())
+	}
+	if !reflect.DeepEqual(got, order) {
+		t.Errorf("unexpected files: expected=%v, actual=%v", order, got)
+	}
+}
+
+func TestEachUploadedFile_Errors(t *testing.T) {
+	files := map[string]string{"a.txt": "first file", "b.txt": "second file"}
+	order := []string{"a.txt", "b.txt"}
+
+	t.Run("callback error stops iteration", func(t *testing.T) {
+		body, contentType := multipartBody(t, files, order)
+		req := httptest.NewRequest(http.MethodPost, "/upload", body)
+		req.Header.Set("Content-Type", contentType)
+
+		stop := errors.New("stop")
+		calls := 0
+		err := EachUploadedFile(req, func(filename string, file io.Reader) error {
+			calls++
+			return stop
+		})
+		if err != stop || calls != 1 {
+			t.Errorf("expected the callback error after one call, got %v after %d calls", err, calls)
+		}
+	})
+
+	t.Run("not multipart", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodPost, "/upload", strings.NewReader("plain"))
+		req.Header.Set("Content-Type", "text/plain")
+
+		err := EachUploadedFile(req, func(filename string, file io.Reader) error { return nil })
+		if err != http.ErrNotMultipart {
+			t.Errorf("expected http.ErrNotMultipart, got %v", err)
+		}
+	})
+}
@@ -0,0 +1,90 @@
+package muxer
+
+import (
+	"net/http"
+	"reflect"
+	"runtime"
+)
+
+/*
+UseNamed registers a middleware function like Use, under a name reported by
+DescribeChain.
+
+	router.UseNamed("request-id", middleware.RequestID())
+*/
+func (r *Router) UseNamed(name string, mw func(http.Handler) http.Handler) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.middleware = append(r.middleware, mw)
+	r.middlewareNames = append(r.middlewareNames, name)
+}
+
+/*
+UseNamed registers middleware for this route only like Use, under a name reported
+by DescribeChain.
+
+	router.HandleRoute(http.MethodDelete, "/users/:id", deleteUser).UseNamed("admin", requireAdmin)
+*/
+func (r *Route) UseNamed(name string, mw func(http.Handler) http.Handler) *Route {
+	r.router.mu.Lock()
+	defer r.router.mu.Unlock()
+
+	r.addMiddleware(name, mw)
+	return r
+}
+
+/*
+DescribeChain returns the names of the middleware that would wrap the route matching
+method and path, in execution order: the router's global middleware followed by the
+route-local middleware. Subrouters are followed as for a request. Middleware
+registered with UseNamed are reported by their name, others by their function name,
+both recorded when the middleware was registered. It returns nil if no route
+matches.
+
+	router.UseNamed("request-id", middleware.RequestID())
+	router.HandleRoute(http.MethodGet, "/reports", reports).Compress()
+	router.DescribeChain(http.MethodGet, "/reports") // ["request-id", "github.com/shellfu/muxer/middleware.Gzip"]
+*/
+func (r *Router) DescribeChain(method, path string) []string {
+	req, err := http.NewRequest(method, path, nil)
+	if err != nil {
+		return nil
+	}
+
+	router := r
+	for {
+		subrouter, _, _, _ := router.matchSubrouter(req)
+		if subrouter == nil {
+			break
+		}
+		router = subrouter
+	}
+
+	router.mu.RLock()
+	route, _, _ := router.matchRoute(method, req)
+	globalNames := router.middlewareNames
+	var routeNames []string
+	if route != nil {
+		routeNames = route.primary().middlewareNames
+	}
+	router.mu.RUnlock()
+
+	if route == nil {
+		return nil
+	}
+	return append(append([]string{}, globalNames...), routeNames...)
+}
+
+// middlewareName returns the function name of mw, reported by DescribeChain for
+// middleware registered without a name.
+func middlewareName(mw func(http.Handler) http.Handler) string {
+	return runtime.FuncForPC(reflect.ValueOf(mw).Pointer()).Name()
+}
+
+// addMiddleware appends mw to the route's middleware under name. The caller must
+// hold r.router.mu.
+func (r *Route) addMiddleware(name string, mw func(http.Handler) http.Handler) {
+	r.middleware = append(r.middleware, mw)
+	r.middlewareNames = append(r.middlewareNames, name)
+}
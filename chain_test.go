@@ -0,0 +1,59 @@
+package muxer
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+)
+
+func TestDescribeChain(t *testing.T) {
+	var calls []string
+	constructed := 0
+	tag := func(name string) func(http.Handler) http.Handler {
+		return func(next http.Handler) http.Handler {
+			constructed++
+			return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				calls = append(calls, name)
+				next.ServeHTTP(w, r)
+			})
+		}
+	}
+
+	router := NewRouter()
+	router.UseNamed("request-id", tag("request-id"))
+	router.UseNamed("logger", tag("logger"))
+	router.HandleRoute(http.MethodGet, "/reports", func(w http.ResponseWriter, r *http.Request) {}).
+		UseNamed("auth", tag("auth")).
+		Compress()
+
+	api := router.Subrouter("/api")
+	api.UseNamed("cors", tag("cors"))
+	api.HandleRoute(http.MethodGet, "/users/:id", func(w http.ResponseWriter, r *http.Request) {})
+
+	tests := []struct {
+		path     string
+		expected []string
+	}{
+		{"/reports", []string{"request-id", "logger", "auth", "github.com/shellfu/muxer/middleware.Gzip"}},
+		{"/api/users/1", []string{"request-id", "logger", "cors"}},
+		{"/unknown", nil},
+	}
+
+	for _, tc := range tests {
+		if chain := router.DescribeChain(http.MethodGet, tc.path); !reflect.DeepEqual(chain, tc.expected) {
+			t.Errorf("unexpected chain for %s: expected=%v, actual=%v", tc.path, tc.expected, chain)
+		}
+	}
+
+	// Describing the chain does not construct the middleware
+	if constructed != 0 {
+		t.Errorf("unexpected middleware constructions: expected=%d, actual=%d", 0, constructed)
+	}
+
+	// The described order matches the order the middleware run in
+	router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/reports", nil))
+	if expected := []string{"request-id", "logger", "auth"}; !reflect.DeepEqual(calls, expected) {
+		t.Errorf("unexpected execution order: expected=%v, actual=%v", expected, calls)
+	}
+}
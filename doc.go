@@ -21,7 +21,8 @@ processing.
 The Router type also supports error handling using the NotFoundHandler and PanicHandler fields. The
 NotFoundHandler is executed when a request is made for a path that does not match any registered route,
 and returns a 404 Not Found HTTP status code. The PanicHandler is executed when a panic occurs during
-route processing, and can be used to handle and recover from unexpected errors.
+route processing, and can be used to handle and recover from unexpected errors. Without one, panics
+are answered with 500 Internal Server Error.
 
 	Example usage:
 
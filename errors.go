@@ -0,0 +1,174 @@
+package muxer
+
+import (
+	"encoding/json"
+	"errors"
+	"math"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/shellfu/muxer/middleware"
+)
+
+/*
+HTTPError is an error carrying an HTTP status code and a message for the client.
+Returning one from a handler registered with HandleErr renders it with its status
+instead of a generic 500.
+
+	router.HandleErr(http.MethodGet, "/admin", func(w http.ResponseWriter, r *http.Request) error {
+	    if !isAdmin(r) {
+	        return muxer.HTTPError{Status: http.StatusForbidden, Message: "no access"}
+	    }
+	    // ...
+	    return nil
+	})
+*/
+type HTTPError struct {
+	Status  int
+	Message string
+}
+
+// Error returns the status code and message of the error.
+func (e HTTPError) Error() string {
+	return strconv.Itoa(e.Status) + " " + e.StatusMessage()
+}
+
+// StatusCode returns the HTTP status code of the error.
+func (e HTTPError) StatusCode() int {
+	return e.Status
+}
+
+// StatusMessage returns the message for the client, falling back to the status text.
+func (e HTTPError) StatusMessage() string {
+	if e.Message == "" {
+		return http.StatusText(e.Status)
+	}
+	return e.Message
+}
+
+// classifyPanic is the PanicClassifier of WithRecovery. It answers a panicked
+// HTTPError with its status and message and defers to StatusCodeClassifier otherwise.
+func classifyPanic(err interface{}) (status int, message string, ok bool) {
+	var httpErr HTTPError
+	switch e := err.(type) {
+	case HTTPError:
+		httpErr = e
+	case *HTTPError:
+		if e == nil {
+			return 0, "", false
+		}
+		httpErr = *e
+	default:
+		return middleware.StatusCodeClassifier(err)
+	}
+	if httpErr.Status < 400 || httpErr.Status > 599 {
+		return 0, "", false
+	}
+	return httpErr.Status, httpErr.StatusMessage(), true
+}
+
+/*
+Abort writes an error response with the given status and message. Called from a
+route's handler, it renders the error like a HandleErr error, in the router's error
+content type (see WithErrorContentType). Otherwise, if the response Content-Type is
+already set to JSON, for example by middleware, the message is written as
+{"error": msg}, and as plain text like http.Error if not. The handler should return
+after calling Abort.
+
+	if !allowed {
+	    muxer.Abort(w, http.StatusForbidden, "no access")
+	    return
+	}
+*/
+func Abort(w http.ResponseWriter, status int, msg string) {
+	if ew, ok := w.(*errorWriter); ok {
+		ew.router.renderError(w, HTTPError{Status: status, Message: msg})
+		return
+	}
+	writeError(w, HTTPError{Status: status, Message: msg})
+}
+
+// errorWriter is the response writer route handlers are called with when the router
+// has error settings, so Abort can render errors as the router does.
+type errorWriter struct {
+	*middleware.ResponseRecorder
+	router *Router
+}
+
+// withErrorWriter wraps handler to be called with an errorWriter if the router has
+// settings affecting how errors are rendered.
+func (r *Router) withErrorWriter(handler http.Handler) http.Handler {
+	if r.errorContentType == "" && r.retryAfter <= 0 {
+		return handler
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		handler.ServeHTTP(&errorWriter{ResponseRecorder: middleware.WrapResponseWriter(w), router: r}, req)
+	})
+}
+
+/*
+HandleErr registers a route whose handler returns an error. A returned HTTPError is
+rendered with its status and message; any other error results in a 500 Internal
+Server Error without exposing the error text. Errors are rendered using the router's
+error content type (see WithErrorContentType).
+*/
+func (r *Router) HandleErr(method, path string, fn func(http.ResponseWriter, *http.Request) error) *Route {
+	return r.HandleRoute(method, path, func(w http.ResponseWriter, req *http.Request) {
+		if err := fn(w, req); err != nil {
+			r.renderError(w, err)
+		}
+	})
+}
+
+// renderError writes err as an error response in the router's error content type.
+func (r *Router) renderError(w http.ResponseWriter, err error) {
+	httpErr := HTTPError{Status: http.StatusInternalServerError}
+
+	var value HTTPError
+	var pointer *HTTPError
+	switch {
+	case errors.As(err, &value):
+		httpErr = value
+	case errors.As(err, &pointer) && pointer != nil:
+		httpErr = *pointer
+	}
+
+	if r.errorContentType != "" {
+		w.Header().Set("Content-Type", r.errorContentType)
+	}
+	r.writeError(w, httpErr)
+}
+
+// writeError writes an error response generated by the router, applying router-wide
+// settings such as the Retry-After header set with WithRetryAfter.
+func (r *Router) writeError(w http.ResponseWriter, err HTTPError) {
+	if err.Status == http.StatusServiceUnavailable && r.retryAfter > 0 {
+		seconds := int64(math.Ceil(r.retryAfter.Seconds()))
+		w.Header().Set("Retry-After", strconv.FormatInt(seconds, 10))
+	}
+	writeError(w, err)
+}
+
+// writeError writes the error response, as JSON if the response Content-Type is JSON
+// and as plain text otherwise.
+func writeError(w http.ResponseWriter, err HTTPError) {
+	if !isJSONContentType(w.Header().Get("Content-Type")) {
+		http.Error(w, err.StatusMessage(), err.Status)
+		return
+	}
+
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	w.WriteHeader(err.Status)
+	json.NewEncoder(w).Encode(map[string]string{"error": err.StatusMessage()}) // nolint: errcheck
+}
+
+// isJSONContentType reports whether contentType is a JSON media type.
+func isJSONContentType(contentType string) bool {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return false
+	}
+	return mediaType == "application/json" || strings.HasSuffix(mediaType, "+json")
+}
@@ -0,0 +1,161 @@
+package muxer
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestAbort(t *testing.T) {
+	tests := []struct {
+		name         string
+		contentType  string
+		expectedBody string
+	}{
+		{"plain text", "", "no access\n"},
+		{"json", "application/json", `{"error":"no access"}` + "\n"},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			if tc.contentType != "" {
+				w.Header().Set("Content-Type", tc.contentType)
+			}
+
+			Abort(w, http.StatusForbidden, "no access")
+
+			if w.Code != http.StatusForbidden {
+				t.Errorf("unexpected status code: expected=%d, actual=%d", http.StatusForbidden, w.Code)
+			}
+			if w.Body.String() != tc.expectedBody {
+				t.Errorf("unexpected response body: expected=%q, actual=%q", tc.expectedBody, w.Body.String())
+			}
+		})
+	}
+}
+
+func TestErrorContentType_Inherited(t *testing.T) {
+	router := NewRouter(WithErrorContentType("application/json"))
+	failing := func(w http.ResponseWriter, r *http.Request) error {
+		return errors.New("boom")
+	}
+	router.Subrouter("/api").HandleErr(http.MethodGet, "/fail", failing)
+	clone := router.CloneConfig()
+	clone.HandleErr(http.MethodGet, "/fail", failing)
+
+	aborting := NewRouter(WithErrorContentType("application/json"))
+	aborting.Use(func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Content-Type", "application/json")
+			next.ServeHTTP(w, r)
+		})
+	})
+	aborting.HandleRoute(http.MethodGet, "/fail", func(w http.ResponseWriter, r *http.Request) {
+		Abort(w, http.StatusInternalServerError, "Internal Server Error")
+	})
+
+	abortingRouter := NewRouter(WithErrorContentType("application/json"))
+	abort := func(w http.ResponseWriter, r *http.Request) {
+		Abort(w, http.StatusInternalServerError, "Internal Server Error")
+	}
+	abortingRouter.HandleRoute(http.MethodGet, "/fail", abort)
+	abortingRouter.Subrouter("/api").HandleRoute(http.MethodGet, "/fail", abort)
+
+	tests := []struct {
+		name   string
+		router *Router
+		path   string
+	}{
+		{"subrouter", router, "/api/fail"},
+		{"clone", clone, "/fail"},
+		{"abort with JSON set by middleware", aborting, "/fail"},
+		{"abort", abortingRouter, "/fail"},
+		{"abort in subrouter", abortingRouter, "/api/fail"},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			tc.router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, tc.path, nil))
+
+			if w.Code != http.StatusInternalServerError {
+				t.Errorf("unexpected status code: expected=%d, actual=%d", http.StatusInternalServerError, w.Code)
+			}
+			if ct := w.Header().Get("Content-Type"); ct != "application/json" {
+				t.Errorf("unexpected content type: expected=%q, actual=%q", "application/json", ct)
+			}
+			if expected := `{"error":"Internal Server Error"}` + "\n"; w.Body.String() != expected {
+				t.Errorf("unexpected response body: expected=%q, actual=%q", expected, w.Body.String())
+			}
+		})
+	}
+}
+
+func TestRouter_HandleErr(t *testing.T) {
+	tests := []struct {
+		name                string
+		options             []RouterOption
+		err                 error
+		expectedCode        int
+		expectedBody        string
+		expectedContentType string
+	}{
+		{
+			name:                "HTTPError rendered as text",
+			err:                 HTTPError{Status: http.StatusForbidden, Message: "no access"},
+			expectedCode:        http.StatusForbidden,
+			expectedBody:        "no access\n",
+			expectedContentType: "text/plain; charset=utf-8",
+		},
+		{
+			name:                "wrapped HTTPError pointer rendered as JSON",
+			options:             []RouterOption{WithErrorContentType("application/json")},
+			err:                 fmt.Errorf("loading user: %w", &HTTPError{Status: http.StatusNotFound, Message: "user not found"}),
+			expectedCode:        http.StatusNotFound,
+			expectedBody:        `{"error":"user not found"}` + "\n",
+			expectedContentType: "application/json",
+		},
+		{
+			name:                "plain error hides details",
+			err:                 errors.New("database connection refused"),
+			expectedCode:        http.StatusInternalServerError,
+			expectedBody:        "Internal Server Error\n",
+			expectedContentType: "text/plain; charset=utf-8",
+		},
+		{
+			name:         "nil error leaves response to handler",
+			err:          nil,
+			expectedCode: http.StatusOK,
+			expectedBody: "ok",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			router := NewRouter(tc.options...)
+			router.HandleErr(http.MethodGet, "/users/:id", func(w http.ResponseWriter, r *http.Request) error {
+				if tc.err != nil {
+					return tc.err
+				}
+				_, err := w.Write([]byte("ok"))
+				return err
+			})
+
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/users/123", nil))
+
+			if w.Code != tc.expectedCode {
+				t.Errorf("unexpected status code: expected=%d, actual=%d", tc.expectedCode, w.Code)
+			}
+			if w.Body.String() != tc.expectedBody {
+				t.Errorf("unexpected response body: expected=%q, actual=%q", tc.expectedBody, w.Body.String())
+			}
+			if tc.expectedContentType != "" && w.Header().Get("Content-Type") != tc.expectedContentType {
+				t.Errorf("unexpected Content-Type: expected=%q, actual=%q", tc.expectedContentType, w.Header().Get("Content-Type"))
+			}
+		})
+	}
+}
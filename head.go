@@ -0,0 +1,15 @@
+package muxer
+
+import "net/http"
+
+// headResponseWriter discards the response body written for a HEAD request while
+// passing through the headers, including any Content-Length set by the handler,
+// and the status code.
+type headResponseWriter struct {
+	http.ResponseWriter
+}
+
+// Write discards p, reporting it as written so handlers behave as for GET.
+func (w *headResponseWriter) Write(p []byte) (int, error) {
+	return len(p), nil
+}
@@ -0,0 +1,127 @@
+package muxer
+
+import (
+	"net/http"
+	"sort"
+	"strings"
+)
+
+/*
+RouteInfo describes a registered route for introspection, for example to list the
+routes of a router on an admin endpoint.
+
+Group is the template of the primary route for routes registered with aliases, see
+Route.Alias, and is shared by the route and its aliases. It is empty for routes
+without aliases. Name is the name given with Route.Name, if any. Hits is the
+number of requests the route had served when Routes was called, see Route.Hits.
+*/
+type RouteInfo struct {
+	Method   string
+	Template string
+	Group    string
+	Name     string
+	Hits     int64
+}
+
+/*
+Routes returns a description of the routes registered on the router, in
+registration order, followed by those of its subrouters in the order of their
+attribute values. The templates of subrouter routes, and their groups, are prefixed
+with the attribute value of the subrouter, e.g. "/api/users/:id" or
+"admin.example.com/users".
+*/
+func (r *Router) Routes() []RouteInfo {
+	infos := make([]RouteInfo, 0)
+	r.Walk(func(route *Route, method, prefix string) error { // nolint: errcheck
+		info := RouteInfo{
+			Method:   method,
+			Template: prefix + route.template,
+			Name:     route.name,
+			Hits:     route.Hits(),
+		}
+		if group := route.group(); group != "" {
+			info.Group = prefix + group
+		}
+		infos = append(infos, info)
+		return nil
+	})
+	return infos
+}
+
+/*
+Walk calls fn for every route registered on the router, in the order of Routes:
+the router's own routes in registration order, then those of its subrouters,
+descending with prefix accumulating their attribute values. If fn returns an error,
+the walk stops and Walk returns it.
+
+	router.Walk(func(route *muxer.Route, method, prefix string) error {
+	    template, _ := route.PathTemplate()
+	    fmt.Println(method, prefix+template, route.Params())
+	    return nil
+	})
+*/
+func (r *Router) Walk(fn func(route *Route, method string, prefix string) error) error {
+	return r.walk("", fn)
+}
+
+// walk implements Walk for the routes of r and its subrouters, prefixed by prefix.
+func (r *Router) walk(prefix string, fn func(route *Route, method string, prefix string) error) error {
+	r.mu.RLock()
+	routes := r.routes
+	attrValues := make([]string, 0, len(r.subrouters))
+	for attrValue := range r.subrouters {
+		attrValues = append(attrValues, attrValue)
+	}
+	sort.Strings(attrValues)
+	subrouters := make([]*Router, 0, len(attrValues))
+	for _, attrValue := range attrValues {
+		subrouters = append(subrouters, r.subrouters[attrValue])
+	}
+	r.mu.RUnlock()
+
+	for _, route := range routes {
+		if err := fn(route, route.method, prefix); err != nil {
+			return err
+		}
+	}
+	for i, subrouter := range subrouters {
+		if err := subrouter.walk(prefix+attrValues[i], fn); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// discovery holds the configuration of WithDiscovery. router is the router the
+// option was applied to, whose routes are listed.
+type discovery struct {
+	prefix  string
+	handler func(w http.ResponseWriter, r *http.Request, routes []RouteInfo)
+	router  *Router
+}
+
+// matches reports whether the request path, including the prefixes of the
+// subrouters it passed through, falls under the discovery prefix.
+func (d *discovery) matches(req *http.Request) bool {
+	path := req.URL.Path
+	if prefix := MatchedPrefix(req); strings.HasPrefix(prefix, "/") {
+		path = prefix + path
+	}
+	return hasPathPrefix(path, d.prefix)
+}
+
+// serve passes the request to the discovery handler with the routes under the prefix.
+func (d *discovery) serve(w http.ResponseWriter, req *http.Request) {
+	routes := make([]RouteInfo, 0)
+	for _, info := range d.router.Routes() {
+		if hasPathPrefix(info.Template, d.prefix) {
+			routes = append(routes, info)
+		}
+	}
+	d.handler(w, req, routes)
+}
+
+// hasPathPrefix reports whether path is prefix or lies below it.
+func hasPathPrefix(path, prefix string) bool {
+	return path == prefix || strings.HasPrefix(path, prefix+"/")
+}
@@ -0,0 +1,123 @@
+package muxer
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+)
+
+func TestRoutes(t *testing.T) {
+	router := NewRouter()
+	handler := func(w http.ResponseWriter, r *http.Request) {}
+
+	router.HandleRoute(http.MethodGet, "/users/:id", handler)
+	router.HandleMany(http.MethodGet, []string{"/login", "/signin"}, handler)
+	router.HandleRoute(http.MethodPost, "/login", handler).Name("login")
+
+	api := router.Subrouter("/api")
+	api.HandleRoute(http.MethodGet, "/items/:id", handler).Name("item")
+	api.Subrouter("/v2").HandleRoute(http.MethodDelete, "/items/:id", handler)
+	router.Subrouter("admin.example.com").HandleRoute(http.MethodGet, "/", handler)
+
+	expected := []RouteInfo{
+		{Method: http.MethodGet, Template: "/users/:id"},
+		{Method: http.MethodGet, Template: "/login", Group: "/login"},
+		{Method: http.MethodGet, Template: "/signin", Group: "/login"},
+		{Method: http.MethodPost, Template: "/login", Name: "login"},
+		{Method: http.MethodGet, Template: "/api/items/:id", Name: "item"},
+		{Method: http.MethodDelete, Template: "/api/v2/items/:id"},
+		{Method: http.MethodGet, Template: "admin.example.com/"},
+	}
+
+	if routes := router.Routes(); !reflect.DeepEqual(routes, expected) {
+		t.Errorf("unexpected routes:\nexpected=%+v\nactual=%+v", expected, routes)
+	}
+}
+
+func TestWalk(t *testing.T) {
+	router := NewRouter()
+	handler := func(w http.ResponseWriter, r *http.Request) {}
+
+	router.HandleRoute(http.MethodGet, "/users/:id", handler)
+	api := router.Subrouter("/api")
+	api.HandleRoute(http.MethodPost, "/items", handler)
+	api.Subrouter("/v2").HandleRoute(http.MethodDelete, "/items/:id", handler)
+	router.HandleRoute(http.MethodGet, "/health", handler)
+
+	var visited []string
+	err := router.Walk(func(route *Route, method, prefix string) error {
+		template, _ := route.PathTemplate()
+		visited = append(visited, method+" "+prefix+template)
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	expected := []string{"GET /users/:id", "GET /health", "POST /api/items", "DELETE /api/v2/items/:id"}
+	if !reflect.DeepEqual(visited, expected) {
+		t.Errorf("unexpected routes visited:\nexpected=%v\nactual=%v", expected, visited)
+	}
+
+	stop := errors.New("stop")
+	visited = nil
+	err = router.Walk(func(route *Route, method, prefix string) error {
+		visited = append(visited, route.template)
+		if prefix == "/api" {
+			return stop
+		}
+		return nil
+	})
+	if err != stop {
+		t.Errorf("unexpected error: expected=%v, actual=%v", stop, err)
+	}
+	if len(visited) != 3 {
+		t.Errorf("unexpected number of routes visited: expected=%d, actual=%d", 3, len(visited))
+	}
+}
+
+func TestWithDiscovery(t *testing.T) {
+	router := NewRouter(WithDiscovery("/api", func(w http.ResponseWriter, r *http.Request, routes []RouteInfo) {
+		w.WriteHeader(http.StatusNotFound)
+		for _, route := range routes {
+			fmt.Fprintln(w, route.Method, route.Template)
+		}
+	}))
+	handler := func(w http.ResponseWriter, r *http.Request) {}
+
+	router.HandleRoute(http.MethodGet, "/api/users", handler)
+	router.HandleRoute(http.MethodGet, "/api/users/:id", handler)
+	router.HandleRoute(http.MethodGet, "/health", handler)
+	router.Subrouter("/api/v2").HandleRoute(http.MethodGet, "/items", handler)
+
+	discoveryDoc := "GET /api/users\nGET /api/users/:id\nGET /api/v2/items\n"
+	tests := []struct {
+		method       string
+		path         string
+		expectedCode int
+		expectedBody string
+	}{
+		{http.MethodGet, "/api/unknown", http.StatusNotFound, discoveryDoc},
+		{http.MethodGet, "/api", http.StatusNotFound, discoveryDoc},
+		{http.MethodGet, "/api/v2/unknown", http.StatusNotFound, discoveryDoc},
+		{http.MethodGet, "/apiary", http.StatusNotFound, "404 page not found\n"},
+		{http.MethodGet, "/unknown", http.StatusNotFound, "404 page not found\n"},
+		{http.MethodDelete, "/api/users", http.StatusMethodNotAllowed, "Method not allowed\n"},
+		{http.MethodGet, "/api/users", http.StatusOK, ""},
+	}
+
+	for _, tc := range tests {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(tc.method, tc.path, nil))
+
+		if w.Code != tc.expectedCode {
+			t.Errorf("unexpected status code for %s %s: expected=%d, actual=%d", tc.method, tc.path, tc.expectedCode, w.Code)
+		}
+		if w.Body.String() != tc.expectedBody {
+			t.Errorf("unexpected response body for %s %s: expected=%q, actual=%q", tc.method, tc.path, tc.expectedBody, w.Body.String())
+		}
+	}
+}
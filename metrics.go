@@ -0,0 +1,195 @@
+package muxer
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/shellfu/muxer/middleware"
+)
+
+// metricsKey is the context key under which the metrics registry of the router
+// collecting metrics is stored, so subrouters record into it.
+const metricsKey contextKey = "metrics"
+
+// metricsBuckets are the upper bounds, in seconds, of the request duration histogram.
+var metricsBuckets = []float64{0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10}
+
+// requestKey identifies a request counter.
+type requestKey struct {
+	route, method string
+	status        int
+}
+
+// durationKey identifies a request duration histogram.
+type durationKey struct {
+	route, method string
+}
+
+// histogram is a cumulative histogram over metricsBuckets.
+type histogram struct {
+	counts []uint64
+	sum    float64
+	count  uint64
+}
+
+// metricsRegistry holds the request counters and duration histograms of a router.
+type metricsRegistry struct {
+	mu        sync.Mutex
+	requests  map[requestKey]uint64
+	durations map[durationKey]*histogram
+}
+
+func newMetricsRegistry() *metricsRegistry {
+	return &metricsRegistry{
+		requests:  make(map[requestKey]uint64),
+		durations: make(map[durationKey]*histogram),
+	}
+}
+
+// observe records a request to route with the given method, status and duration.
+func (m *metricsRegistry) observe(route, method string, status int, duration time.Duration) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.requests[requestKey{route, method, status}]++
+
+	key := durationKey{route, method}
+	h := m.durations[key]
+	if h == nil {
+		h = &histogram{counts: make([]uint64, len(metricsBuckets))}
+		m.durations[key] = h
+	}
+	seconds := duration.Seconds()
+	for i, bound := range metricsBuckets {
+		if seconds <= bound {
+			h.counts[i]++
+		}
+	}
+	h.sum += seconds
+	h.count++
+}
+
+// instrument wraps handler so its requests are recorded for route. Requests whose
+// handler panics before writing a status are recorded with 500.
+func (m *metricsRegistry) instrument(handler http.Handler, route string) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		rec := middleware.WrapResponseWriter(w)
+		start := time.Now()
+		completed := false
+		defer func() {
+			status := rec.Status()
+			if !completed && !rec.WroteHeader() {
+				status = http.StatusInternalServerError
+			}
+			m.observe(route, req.Method, status, time.Since(start))
+		}()
+
+		handler.ServeHTTP(rec, req)
+		completed = true
+	})
+}
+
+// writeTo writes the metrics in the Prometheus text exposition format.
+func (m *metricsRegistry) writeTo(w io.Writer) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	requestKeys := make([]requestKey, 0, len(m.requests))
+	for key := range m.requests {
+		requestKeys = append(requestKeys, key)
+	}
+	sort.Slice(requestKeys, func(i, j int) bool {
+		a, b := requestKeys[i], requestKeys[j]
+		if a.route != b.route {
+			return a.route < b.route
+		}
+		if a.method != b.method {
+			return a.method < b.method
+		}
+		return a.status < b.status
+	})
+
+	fmt.Fprintln(w, "# HELP muxer_requests_total Total number of HTTP requests handled by route, method and status.")
+	fmt.Fprintln(w, "# TYPE muxer_requests_total counter")
+	for _, key := range requestKeys {
+		fmt.Fprintf(w, "muxer_requests_total{route=%s,method=%s,status=\"%d\"} %d\n",
+			quoteLabel(key.route), quoteLabel(key.method), key.status, m.requests[key])
+	}
+
+	durationKeys := make([]durationKey, 0, len(m.durations))
+	for key := range m.durations {
+		durationKeys = append(durationKeys, key)
+	}
+	sort.Slice(durationKeys, func(i, j int) bool {
+		a, b := durationKeys[i], durationKeys[j]
+		if a.route != b.route {
+			return a.route < b.route
+		}
+		return a.method < b.method
+	})
+
+	fmt.Fprintln(w, "# HELP muxer_request_duration_seconds Duration of HTTP requests by route and method.")
+	fmt.Fprintln(w, "# TYPE muxer_request_duration_seconds histogram")
+	for _, key := range durationKeys {
+		h := m.durations[key]
+		labels := "route=" + quoteLabel(key.route) + ",method=" + quoteLabel(key.method)
+		for i, bound := range metricsBuckets {
+			fmt.Fprintf(w, "muxer_request_duration_seconds_bucket{%s,le=\"%s\"} %d\n",
+				labels, strconv.FormatFloat(bound, 'g', -1, 64), h.counts[i])
+		}
+		fmt.Fprintf(w, "muxer_request_duration_seconds_bucket{%s,le=\"+Inf\"} %d\n", labels, h.count)
+		fmt.Fprintf(w, "muxer_request_duration_seconds_sum{%s} %s\n", labels, strconv.FormatFloat(h.sum, 'g', -1, 64))
+		fmt.Fprintf(w, "muxer_request_duration_seconds_count{%s} %d\n", labels, h.count)
+	}
+}
+
+// quoteLabel quotes a label value, escaping backslashes, double quotes and newlines.
+func quoteLabel(value string) string {
+	value = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`).Replace(value)
+	return `"` + value + `"`
+}
+
+/*
+MetricsHandler enables metrics collection on the router and registers a GET route
+at path exposing the metrics in the Prometheus text format, without depending on a
+metrics library. Every request matched by a route of the router or its subrouters
+is counted in muxer_requests_total, labeled with the route template (including the
+prefixes of subrouters), method and status, and timed in the
+muxer_request_duration_seconds histogram. Requests that match no route are not
+recorded.
+
+	router.MetricsHandler("/metrics")
+*/
+func (r *Router) MetricsHandler(path string) *Route {
+	r.mu.Lock()
+	if r.metrics == nil {
+		r.metrics = newMetricsRegistry()
+	}
+	registry := r.metrics
+	r.mu.Unlock()
+
+	return r.HandleRoute(http.MethodGet, path, func(w http.ResponseWriter, req *http.Request) {
+		w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+		registry.writeTo(w)
+	})
+}
+
+// withMetrics stores the router's metrics registry in the request context, unless
+// a parent router already did.
+func (r *Router) withMetrics(req *http.Request) *http.Request {
+	r.mu.RLock()
+	registry := r.metrics
+	r.mu.RUnlock()
+
+	if registry == nil || req.Context().Value(metricsKey) != nil {
+		return req
+	}
+	return req.WithContext(context.WithValue(req.Context(), metricsKey, registry))
+}
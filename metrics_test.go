@@ -0,0 +1,103 @@
+package muxer
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestMetricsHandler(t *testing.T) {
+	router := NewRouter()
+	router.MetricsHandler("/metrics")
+	router.HandleRoute(http.MethodGet, "/users/:id", func(w http.ResponseWriter, r *http.Request) {
+		if Params(r)["id"] == "0" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.Write([]byte("user")) // nolint: errcheck
+	})
+	router.Subrouter("/api").HandleRoute(http.MethodPost, "/orders", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusCreated)
+	})
+
+	requests := []struct {
+		method string
+		path   string
+	}{
+		{http.MethodGet, "/users/1"},
+		{http.MethodGet, "/users/2"},
+		{http.MethodGet, "/users/0"},
+		{http.MethodPost, "/api/orders"},
+		{http.MethodGet, "/unknown"},
+	}
+	for _, req := range requests {
+		router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(req.method, req.path, nil))
+	}
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("unexpected status code: expected=%d, actual=%d", http.StatusOK, w.Code)
+	}
+	if ct := w.Header().Get("Content-Type"); !strings.HasPrefix(ct, "text/plain; version=0.0.4") {
+		t.Errorf("unexpected content type: %q", ct)
+	}
+
+	body := w.Body.String()
+	expectedLines := []string{
+		"# TYPE muxer_requests_total counter",
+		`muxer_requests_total{route="/api/orders",method="POST",status="201"} 1`,
+		`muxer_requests_total{route="/users/:id",method="GET",status="200"} 2`,
+		`muxer_requests_total{route="/users/:id",method="GET",status="404"} 1`,
+		"# TYPE muxer_request_duration_seconds histogram",
+		`muxer_request_duration_seconds_bucket{route="/users/:id",method="GET",le="+Inf"} 3`,
+		`muxer_request_duration_seconds_count{route="/users/:id",method="GET"} 3`,
+		`muxer_request_duration_seconds_count{route="/api/orders",method="POST"} 1`,
+	}
+	for _, line := range expectedLines {
+		if !strings.Contains(body, line+"\n") {
+			t.Errorf("expected metrics to contain %q, got:\n%s", line, body)
+		}
+	}
+	if strings.Contains(body, "/unknown") {
+		t.Errorf("expected unmatched requests not to be recorded, got:\n%s", body)
+	}
+}
+
+func TestMetricsHandler_Passthrough(t *testing.T) {
+	router := NewRouter()
+	router.MetricsHandler("/metrics")
+
+	var flusher, hijacker bool
+	router.HandleRoute(http.MethodGet, "/events", func(w http.ResponseWriter, r *http.Request) {
+		_, flusher = w.(http.Flusher)
+		_, hijacker = w.(http.Hijacker)
+	})
+	router.HandleRoute(http.MethodGet, "/panic", func(w http.ResponseWriter, r *http.Request) {
+		panic("boom")
+	})
+
+	router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/events", nil))
+	if !flusher {
+		t.Error("expected instrumented handlers to keep http.Flusher")
+	}
+	if !hijacker {
+		t.Error("expected instrumented handlers to keep http.Hijacker")
+	}
+
+	router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/panic", nil))
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	if line := `muxer_requests_total{route="/panic",method="GET",status="500"} 1`; !strings.Contains(w.Body.String(), line+"\n") {
+		t.Errorf("expected metrics to contain %q, got:\n%s", line, w.Body.String())
+	}
+}
+
+func TestQuoteLabel(t *testing.T) {
+	if got, expected := quoteLabel("a\"b\\c\nd"), `"a\"b\\c\nd"`; got != expected {
+		t.Errorf("unexpected quoted label: expected=%s, actual=%s", expected, got)
+	}
+}
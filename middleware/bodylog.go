@@ -0,0 +1,133 @@
+package middleware
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+)
+
+// DefaultBodyLogLimit is the default number of body bytes logged by BodyLogger.
+const DefaultBodyLogLimit = 1024
+
+/*
+The bodyLogConfig struct contains the settings of the BodyLogger middleware. MaxBytes
+is the number of bytes of each body that are logged, and Redact, if set, rewrites a
+body before it is logged to hide sensitive fields.
+*/
+type bodyLogConfig struct {
+	MaxBytes int
+	Redact   func(body []byte) []byte
+}
+
+// BodyLogOption is a function that modifies the BodyLogger configuration.
+type BodyLogOption func(*bodyLogConfig)
+
+// WithBodyLogLimit sets the number of bytes of each body that are logged. Longer
+// bodies are truncated in the log; the handler and client still see the full body.
+func WithBodyLogLimit(n int) BodyLogOption {
+	return func(cfg *bodyLogConfig) {
+		cfg.MaxBytes = n
+	}
+}
+
+// WithBodyLogRedactor sets a hook that rewrites request and response bodies before
+// they are logged, for example to mask passwords or tokens.
+func WithBodyLogRedactor(fn func(body []byte) []byte) BodyLogOption {
+	return func(cfg *bodyLogConfig) {
+		cfg.Redact = fn
+	}
+}
+
+/*
+BodyLogger is a debugging middleware that logs request and response bodies to the
+given logger, truncated to a configurable size (DefaultBodyLogLimit by default) and
+optionally redacted. If logger is nil, the default Go logger is used.
+
+The request body is buffered and restored, so the handler still receives it in full.
+The response is captured by a wrapper that writes through to the client, so
+streaming responses are not delayed. Register it only where bodies are being debugged:
+
+	r := muxer.NewRouter()
+	webhooks := r.Subrouter("/webhooks")
+	webhooks.Use(middleware.BodyLogger(
+		myLogger,
+		middleware.WithBodyLogLimit(512),
+		middleware.WithBodyLogRedactor(maskSecrets),
+	))
+*/
+func BodyLogger(logger RecoveryLogger, options ...BodyLogOption) func(http.Handler) http.Handler {
+	cfg := &bodyLogConfig{MaxBytes: DefaultBodyLogLimit}
+	for _, option := range options {
+		option(cfg)
+	}
+
+	logf := func(format string, v ...interface{}) {
+		msg := fmt.Sprintf(format, v...)
+		if logger != nil {
+			logger.Println(msg)
+		} else {
+			log.Println(msg)
+		}
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.Body != nil && r.Body != http.NoBody {
+				body, err := io.ReadAll(r.Body)
+				r.Body.Close()
+				r.Body = io.NopCloser(io.MultiReader(bytes.NewReader(body), errReader{err}))
+				logf("%s %s request body: %s", r.Method, r.URL.Path, cfg.format(body, len(body)))
+			}
+
+			bw := &bodyLogWriter{ResponseRecorder: WrapResponseWriter(w), limit: cfg.MaxBytes}
+			next.ServeHTTP(bw, r)
+
+			logf("%s %s response %d body: %s", r.Method, r.URL.Path, bw.Status(), cfg.format(bw.captured.Bytes(), bw.BytesWritten()))
+		})
+	}
+}
+
+// format redacts and truncates a body of total bytes for logging.
+func (cfg *bodyLogConfig) format(body []byte, total int) string {
+	if cfg.Redact != nil {
+		body = cfg.Redact(body)
+	}
+	if cfg.MaxBytes >= 0 && len(body) > cfg.MaxBytes {
+		return fmt.Sprintf("%s... (truncated, %d bytes)", body[:cfg.MaxBytes], total)
+	}
+	return string(body)
+}
+
+// errReader returns err, if any, once the buffered body has been consumed, so read
+// errors such as an exceeded body size limit still reach the handler.
+type errReader struct {
+	err error
+}
+
+func (r errReader) Read([]byte) (int, error) {
+	if r.err != nil {
+		return 0, r.err
+	}
+	return 0, io.EOF
+}
+
+// A bodyLogWriter wraps an http.ResponseWriter, writing through to the client while
+// capturing the status and the beginning of the body for logging.
+type bodyLogWriter struct {
+	*ResponseRecorder
+	captured bytes.Buffer
+	limit    int
+}
+
+func (w *bodyLogWriter) Write(b []byte) (int, error) {
+	// Capture one byte past the limit so truncation can be detected
+	if remaining := w.limit + 1 - w.captured.Len(); remaining > 0 {
+		if remaining > len(b) {
+			remaining = len(b)
+		}
+		w.captured.Write(b[:remaining])
+	}
+	return w.ResponseRecorder.Write(b)
+}
@@ -0,0 +1,71 @@
+package middleware
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// lineLogger records every line logged through Println.
+type lineLogger struct {
+	lines []string
+}
+
+func (l *lineLogger) Println(v ...interface{}) {
+	for _, msg := range v {
+		l.lines = append(l.lines, msg.(string))
+	}
+}
+
+func TestBodyLogger(t *testing.T) {
+	logger := &lineLogger{}
+	requestBody := `{"user":"gopher","password":"hunter2","padding":"` + strings.Repeat("x", 50) + `"}`
+	responseBody := strings.Repeat("r", 40)
+
+	var received string
+	handler := BodyLogger(
+		logger,
+		WithBodyLogLimit(32),
+		WithBodyLogRedactor(func(body []byte) []byte {
+			return bytes.ReplaceAll(body, []byte("hunter2"), []byte("*****"))
+		}),
+	)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, err := io.ReadAll(r.Body)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		received = string(body)
+		w.WriteHeader(http.StatusCreated)
+		w.Write([]byte(responseBody)) // nolint: errcheck
+	}))
+
+	req := httptest.NewRequest(http.MethodPost, "/webhooks", strings.NewReader(requestBody))
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+
+	if received != requestBody {
+		t.Errorf("expected handler to receive the full request body, got %q", received)
+	}
+	if rec.Body.String() != responseBody {
+		t.Errorf("expected client to receive the full response body, got %q", rec.Body.String())
+	}
+
+	if len(logger.lines) != 2 {
+		t.Fatalf("expected 2 log lines, got %d: %v", len(logger.lines), logger.lines)
+	}
+
+	expectedRequest := fmt.Sprintf(`POST /webhooks request body: {"user":"gopher","password":"***... (truncated, %d bytes)`, len(requestBody))
+	if logger.lines[0] != expectedRequest {
+		t.Errorf("unexpected request log line:\nexpected=%s\nactual=%s", expectedRequest, logger.lines[0])
+	}
+
+	expectedResponse := "POST /webhooks response 201 body: " + strings.Repeat("r", 32) + "... (truncated, 40 bytes)"
+	if logger.lines[1] != expectedResponse {
+		t.Errorf("unexpected response log line:\nexpected=%s\nactual=%s", expectedResponse, logger.lines[1])
+	}
+}
@@ -0,0 +1,211 @@
+package middleware
+
+import (
+	"compress/gzip"
+	"compress/zlib"
+	"io"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Encoder creates a writer compressing into w with a content coding.
+type Encoder func(w io.Writer) io.WriteCloser
+
+type compressConfig struct {
+	Encoders   map[string]Encoder
+	Preference []string
+	ClientWins bool
+}
+
+// CompressOption is a function that modifies the Compress configuration.
+type CompressOption func(*compressConfig)
+
+/*
+WithEncodingPreference sets the order in which the server prefers content codings,
+most preferred first. If clientWins is true, the coding with the highest quality
+value in the request's Accept-Encoding header is chosen and the order only breaks
+ties. If clientWins is false, the first coding in the order that the client accepts
+at all is chosen, regardless of its quality value.
+
+Codings in the order without an encoder are ignored, and supported codings missing
+from the order are least preferred.
+*/
+func WithEncodingPreference(order []string, clientWins bool) CompressOption {
+	return func(cfg *compressConfig) {
+		cfg.Preference = make([]string, 0, len(order))
+		for _, coding := range order {
+			cfg.Preference = append(cfg.Preference, strings.ToLower(coding))
+		}
+		cfg.ClientWins = clientWins
+	}
+}
+
+// WithEncoder registers an encoder for a content coding, such as "br" backed by a
+// third-party brotli package, or replaces a built-in one.
+func WithEncoder(coding string, encoder Encoder) CompressOption {
+	return func(cfg *compressConfig) {
+		cfg.Encoders[strings.ToLower(coding)] = encoder
+	}
+}
+
+/*
+Compress returns a middleware that compresses response bodies with the content
+coding negotiated from the request's Accept-Encoding header. gzip and deflate are
+supported out of the box; other codings can be added with WithEncoder. By default
+the client's quality values decide, and ties go to gzip over deflate; use
+WithEncodingPreference to change the order or let the server preference win.
+
+Responses to clients that accept none of the supported codings, and responses
+without a body such as 204 No Content, are passed through unchanged.
+
+Usage:
+
+	r := muxer.NewRouter()
+	r.Use(middleware.Compress(
+		middleware.WithEncoder("br", newBrotliWriter),
+		middleware.WithEncodingPreference([]string{"br", "gzip"}, false),
+	))
+*/
+func Compress(options ...CompressOption) func(http.Handler) http.Handler {
+	cfg := &compressConfig{
+		Encoders: map[string]Encoder{
+			"gzip": func(w io.Writer) io.WriteCloser {
+				return newPooledGzipWriter(w, gzip.DefaultCompression)
+			},
+			// The HTTP deflate coding is the zlib format, not raw DEFLATE
+			"deflate": func(w io.Writer) io.WriteCloser {
+				return zlib.NewWriter(w)
+			},
+		},
+		Preference: []string{"gzip", "deflate"},
+		ClientWins: true,
+	}
+	for _, option := range options {
+		option(cfg)
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			addVary(w.Header(), "Accept-Encoding")
+
+			coding := cfg.negotiate(r.Header.Values("Accept-Encoding"))
+			if coding == "" {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			cw := &compressResponseWriter{ResponseWriter: w, coding: coding, encoder: cfg.Encoders[coding]}
+			defer cw.Close()
+
+			next.ServeHTTP(cw, r)
+		})
+	}
+}
+
+// negotiate returns the content coding to use for the given Accept-Encoding header
+// values, or "" if the client accepts none of the supported codings.
+func (cfg *compressConfig) negotiate(acceptEncoding []string) string {
+	qualities := parseAcceptEncoding(acceptEncoding)
+	if len(qualities) == 0 {
+		return ""
+	}
+
+	best, bestQuality := "", 0.0
+	for _, coding := range cfg.candidates() {
+		quality, ok := qualities[coding]
+		if !ok {
+			quality, ok = qualities["*"]
+		}
+		if !ok || quality <= 0 {
+			continue
+		}
+		if !cfg.ClientWins {
+			return coding
+		}
+		if quality > bestQuality {
+			best, bestQuality = coding, quality
+		}
+	}
+	return best
+}
+
+// candidates returns the supported codings in order of server preference.
+func (cfg *compressConfig) candidates() []string {
+	seen := make(map[string]bool, len(cfg.Encoders))
+	candidates := make([]string, 0, len(cfg.Encoders))
+	for _, coding := range cfg.Preference {
+		if _, ok := cfg.Encoders[coding]; ok && !seen[coding] {
+			seen[coding] = true
+			candidates = append(candidates, coding)
+		}
+	}
+	for _, coding := range []string{"gzip", "deflate"} {
+		if _, ok := cfg.Encoders[coding]; ok && !seen[coding] {
+			seen[coding] = true
+			candidates = append(candidates, coding)
+		}
+	}
+	var rest []string
+	for coding := range cfg.Encoders {
+		if !seen[coding] {
+			rest = append(rest, coding)
+		}
+	}
+	sort.Strings(rest)
+	return append(candidates, rest...)
+}
+
+/*
+AcceptsEncoding reports whether the Accept-Encoding header values allow the content
+coding, listed or matched by "*" with a non-zero quality value, and do not prefer
+an explicitly listed identity encoding over it. It is shared by the compression
+middleware and the router's static file serving.
+
+	if middleware.AcceptsEncoding(r.Header.Values("Accept-Encoding"), "br") {
+	    // serve the brotli-compressed variant
+	}
+*/
+func AcceptsEncoding(acceptEncoding []string, coding string) bool {
+	qualities := parseAcceptEncoding(acceptEncoding)
+	quality, ok := qualities[strings.ToLower(coding)]
+	if !ok {
+		quality, ok = qualities["*"]
+	}
+	if !ok || quality <= 0 {
+		return false
+	}
+	if identity, ok := qualities["identity"]; ok && identity > quality {
+		return false
+	}
+	return true
+}
+
+// parseAcceptEncoding returns the quality value of each coding listed in the
+// Accept-Encoding header values, normalized to lower case. Codings without a valid
+// quality value default to 1.
+func parseAcceptEncoding(values []string) map[string]float64 {
+	qualities := make(map[string]float64)
+	for _, value := range values {
+		for _, part := range strings.Split(value, ",") {
+			coding, params, _ := strings.Cut(part, ";")
+			coding = strings.ToLower(strings.TrimSpace(coding))
+			if coding == "" {
+				continue
+			}
+
+			quality := 1.0
+			for _, param := range strings.Split(params, ";") {
+				key, val, _ := strings.Cut(strings.TrimSpace(param), "=")
+				if strings.EqualFold(key, "q") {
+					if q, err := strconv.ParseFloat(val, 64); err == nil && q >= 0 && q <= 1 {
+						quality = q
+					}
+				}
+			}
+			qualities[coding] = quality
+		}
+	}
+	return qualities
+}
@@ -0,0 +1,76 @@
+package middleware
+
+import (
+	"compress/zlib"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestCompressEncodingPreference(t *testing.T) {
+	tests := []struct {
+		name             string
+		options          []CompressOption
+		acceptEncoding   string
+		expectedEncoding string
+	}{
+		{"default prefers gzip on tie", nil, "deflate, gzip", "gzip"},
+		{"client q-value wins by default", nil, "gzip;q=0.5, deflate", "deflate"},
+		{"client wins, server order breaks tie", []CompressOption{WithEncodingPreference([]string{"deflate", "gzip"}, true)}, "gzip, deflate", "deflate"},
+		{"client wins over server order", []CompressOption{WithEncodingPreference([]string{"deflate", "gzip"}, true)}, "gzip, deflate;q=0.8", "gzip"},
+		{"server wins over q-values", []CompressOption{WithEncodingPreference([]string{"deflate", "gzip"}, false)}, "gzip, deflate;q=0.1", "deflate"},
+		{"server wins skips refused coding", []CompressOption{WithEncodingPreference([]string{"deflate", "gzip"}, false)}, "gzip, deflate;q=0", "gzip"},
+		{"unknown coding in order ignored", []CompressOption{WithEncodingPreference([]string{"br", "gzip"}, false)}, "br, gzip", "gzip"},
+		{"wildcard", nil, "*", "gzip"},
+		{"no acceptable coding", nil, "br, gzip;q=0", ""},
+		{"no Accept-Encoding", nil, "", ""},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			if tc.acceptEncoding != "" {
+				req.Header.Set("Accept-Encoding", tc.acceptEncoding)
+			}
+			rec := httptest.NewRecorder()
+
+			handler := Compress(tc.options...)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.Write([]byte("hello")) // nolint: errcheck
+			}))
+			handler.ServeHTTP(rec, req)
+
+			if encoding := rec.Header().Get("Content-Encoding"); encoding != tc.expectedEncoding {
+				t.Errorf("expected Content-Encoding %q, got %q", tc.expectedEncoding, encoding)
+			}
+			if rec.Header().Get("Vary") != "Accept-Encoding" {
+				t.Errorf("expected Vary %q, got %q", "Accept-Encoding", rec.Header().Get("Vary"))
+			}
+		})
+	}
+}
+
+func TestCompressDeflate(t *testing.T) {
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set("Accept-Encoding", "deflate")
+	rec := httptest.NewRecorder()
+
+	Compress()(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("hello")) // nolint: errcheck
+	})).ServeHTTP(rec, req)
+
+	if encoding := rec.Header().Get("Content-Encoding"); encoding != "deflate" {
+		t.Fatalf("expected Content-Encoding %q, got %q", "deflate", encoding)
+	}
+	zr, err := zlib.NewReader(rec.Body)
+	if err != nil {
+		t.Fatalf("failed to read zlib body: %v", err)
+	}
+	body, err := io.ReadAll(zr)
+	if err != nil {
+		t.Fatalf("failed to read zlib body: %v", err)
+	}
+	if string(body) != "hello" {
+		t.Errorf("expected body %q, got %q", "hello", body)
+	}
+}
@@ -0,0 +1,53 @@
+package middleware
+
+import (
+	"net/http"
+)
+
+/*
+DefaultContentType is a middleware that sets the Content-Type header of responses
+whose handler did not set one, so net/http does not sniff the type from the body.
+An explicitly set Content-Type, even an empty one, is never overridden. Responses
+without a body, such as 204 No Content and 304 Not Modified, are left alone.
+
+Usage:
+
+	r := muxer.NewRouter()
+	r.Use(middleware.DefaultContentType("application/json"))
+*/
+func DefaultContentType(contentType string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			next.ServeHTTP(&contentTypeWriter{ResponseWriter: w, contentType: contentType}, r)
+		})
+	}
+}
+
+// A contentTypeWriter wraps an http.ResponseWriter and sets a default Content-Type
+// when the response header is written, unless one is set already.
+type contentTypeWriter struct {
+	http.ResponseWriter
+	contentType string
+	wroteHeader bool
+}
+
+func (w *contentTypeWriter) WriteHeader(code int) {
+	if w.wroteHeader {
+		return
+	}
+	w.wroteHeader = true
+
+	if code >= http.StatusOK && code != http.StatusNoContent && code != http.StatusNotModified {
+		if _, ok := w.Header()["Content-Type"]; !ok {
+			w.Header().Set("Content-Type", w.contentType)
+		}
+	}
+	w.ResponseWriter.WriteHeader(code)
+}
+
+func (w *contentTypeWriter) Write(b []byte) (int, error) {
+	if !w.wroteHeader {
+		w.WriteHeader(http.StatusOK)
+	}
+	return w.ResponseWriter.Write(b)
+}
@@ -0,0 +1,66 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestDefaultContentType(t *testing.T) {
+	tests := []struct {
+		name                string
+		handler             http.HandlerFunc
+		expectedCode        int
+		expectedContentType string
+	}{
+		{
+			name: "default applied on write",
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				w.Write([]byte("<html></html>")) // nolint: errcheck
+			},
+			expectedCode:        http.StatusOK,
+			expectedContentType: "application/json",
+		},
+		{
+			name: "default applied on explicit status",
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(http.StatusCreated)
+				w.Write([]byte("{}")) // nolint: errcheck
+			},
+			expectedCode:        http.StatusCreated,
+			expectedContentType: "application/json",
+		},
+		{
+			name: "explicit type wins",
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+				w.Write([]byte("hello")) // nolint: errcheck
+			},
+			expectedCode:        http.StatusOK,
+			expectedContentType: "text/plain; charset=utf-8",
+		},
+		{
+			name: "no content is left alone",
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(http.StatusNoContent)
+			},
+			expectedCode: http.StatusNoContent,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			handler := DefaultContentType("application/json")(tc.handler)
+
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil))
+
+			if w.Code != tc.expectedCode {
+				t.Errorf("expected status code %d, got %d", tc.expectedCode, w.Code)
+			}
+			if got := w.Header().Get("Content-Type"); got != tc.expectedContentType {
+				t.Errorf("expected Content-Type %q, got %q", tc.expectedContentType, got)
+			}
+		})
+	}
+}
@@ -1,7 +1,9 @@
 package middleware
 
 import (
+	"context"
 	"net/http"
+	"sort"
 	"strconv"
 	"strings"
 )
@@ -10,26 +12,74 @@ import (
 The corsConfig struct contains the allowed origins, methods, and headers for the CORS.
 The AllowCredentials field is used to allow or deny sending credentials such as cookies
 or HTTP authentication. The MaxAge field is used to set the maximum age of the preflight
-request cache.
+request cache. AllowedHeaderOrder records the order in which allowed headers were added,
+so the Access-Control-Allow-Headers value is deterministic. ExposedHeaders lists the
+response headers browsers let cross-origin scripts read. AllowOriginFunc, if set,
+allows origins beyond AllowedOrigins. ReflectRequestHeaders makes preflight responses
+allow the headers a request asks for when no AllowedHeaders are configured.
+PreflightStatus is the status of preflight responses, 200 OK if zero.
 */
 type corsConfig struct {
-	AllowedOrigins   []string
-	AllowedMethods   []string
-	AllowedHeaders   map[string]string
-	PreflightHeaders map[string]string
-	MaxAge           int
+	AllowedOrigins        []string
+	AllowOriginFunc       func(origin string) bool
+	AllowedMethods        []string
+	AllowedHeaders        map[string]string
+	AllowedHeaderOrder    []string
+	PreflightHeaders      map[string]string
+	ExposedHeaders        []string
+	AllowCredentials      bool
+	ReflectRequestHeaders bool
+	PreflightStatus       int
+	MaxAge                int
+}
+
+// addAllowedHeader adds or updates an allowed header, preserving insertion order.
+func (cfg *corsConfig) addAllowedHeader(header, value string) {
+	if _, ok := cfg.AllowedHeaders[header]; !ok {
+		cfg.AllowedHeaderOrder = append(cfg.AllowedHeaderOrder, header)
+	}
+	cfg.AllowedHeaders[header] = value
+}
+
+type contextKey string
+
+// explicitOptionsKey marks a request as dispatched to an explicitly registered OPTIONS route.
+const explicitOptionsKey contextKey = "explicit_options"
+
+// WithExplicitOptions returns a copy of ctx marking the request as being served by an
+// explicitly registered OPTIONS route. The muxer Router sets this mark when it dispatches
+// to such a route, and the CORS middleware then leaves the response to that route's handler.
+func WithExplicitOptions(ctx context.Context) context.Context {
+	return context.WithValue(ctx, explicitOptionsKey, true)
+}
+
+// isExplicitOptions reports whether the request was marked by WithExplicitOptions.
+func isExplicitOptions(r *http.Request) bool {
+	explicit, _ := r.Context().Value(explicitOptionsKey).(bool)
+	return explicit
 }
 
 // CORSOption is a function that modifies the CORSConfig.
 type CORSOption func(*corsConfig)
 
-// WithAllowedOrigins sets the list of allowed origins in the CORSConfig.
+// WithAllowedOrigins sets the list of allowed origins in the CORSConfig. An entry
+// like "https://*.example.com" allows every subdomain of example.com over https, and
+// "*.example.com" over any scheme; the domain itself must be listed separately.
 func WithAllowedOrigins(origins ...string) CORSOption {
 	return func(cfg *corsConfig) {
 		cfg.AllowedOrigins = origins
 	}
 }
 
+// WithAllowOriginFunc sets a function deciding at request time whether an origin is
+// allowed, for example by looking it up in a database. It is consulted for origins
+// that AllowedOrigins does not allow, so both can be combined.
+func WithAllowOriginFunc(fn func(origin string) bool) CORSOption {
+	return func(cfg *corsConfig) {
+		cfg.AllowOriginFunc = fn
+	}
+}
+
 // WithAllowedMethods sets the list of allowed methods in the CORSConfig.
 func WithAllowedMethods(methods ...string) CORSOption {
 	return func(cfg *corsConfig) {
@@ -42,12 +92,12 @@ func WithAllowedMethods(methods ...string) CORSOption {
 // It creates a new map with the header names as keys and empty string values.
 // The map is then set as the AllowedHeaders field in the corsConfig struct.
 func WithAllowedHeaders(headers ...string) CORSOption {
-	headerMap := make(map[string]string, len(headers))
-	for _, header := range headers {
-		headerMap[header] = ""
-	}
 	return func(cfg *corsConfig) {
-		cfg.AllowedHeaders = headerMap
+		cfg.AllowedHeaders = make(map[string]string, len(headers))
+		cfg.AllowedHeaderOrder = nil
+		for _, header := range headers {
+			cfg.addAllowedHeader(header, "")
+		}
 	}
 }
 
@@ -56,9 +106,51 @@ func WithAllowedHeaders(headers ...string) CORSOption {
 // The map is merged with the existing AllowedHeaders field in the corsConfig struct.
 func WithAllowedHeadersAndValues(headers map[string]string) CORSOption {
 	return func(cfg *corsConfig) {
-		for k, v := range headers {
-			cfg.AllowedHeaders[k] = v
+		for _, k := range sortedKeys(headers) {
+			cfg.addAllowedHeader(k, headers[k])
+		}
+	}
+}
+
+// WithExposedHeaders sets the response headers, beyond the CORS-safelisted ones,
+// that browsers expose to cross-origin scripts through Access-Control-Expose-Headers.
+func WithExposedHeaders(headers ...string) CORSOption {
+	return func(cfg *corsConfig) {
+		cfg.ExposedHeaders = headers
+	}
+}
+
+// WithAllowCredentials allows cross-origin requests to include credentials such as
+// cookies or HTTP authentication, by sending Access-Control-Allow-Credentials: true.
+// As the spec forbids combining credentials with a wildcard origin, requests from
+// origins that are not allowed then get no Access-Control-Allow-Origin header at all
+// instead of "*".
+func WithAllowCredentials() CORSOption {
+	return func(cfg *corsConfig) {
+		cfg.AllowCredentials = true
+	}
+}
+
+// WithReflectRequestHeaders makes preflight responses echo the request's
+// Access-Control-Request-Headers in Access-Control-Allow-Headers, allowing whatever
+// headers the client asks for. It only applies while no allowed headers are
+// configured with WithAllowedHeaders or WithAllowedHeadersAndValues, which take
+// precedence.
+func WithReflectRequestHeaders() CORSOption {
+	return func(cfg *corsConfig) {
+		cfg.ReflectRequestHeaders = true
+	}
+}
+
+// WithPreflightStatus sets the status code the middleware answers preflight requests
+// with, such as 204 No Content as preferred by the fetch spec; a code of 0 selects
+// 204. Without the option, preflight requests are answered with 200 OK.
+func WithPreflightStatus(code int) CORSOption {
+	return func(cfg *corsConfig) {
+		if code == 0 {
+			code = http.StatusNoContent
 		}
+		cfg.PreflightStatus = code
 	}
 }
 
@@ -120,25 +212,29 @@ Alternatively, you can create a custom CORS middleware with specific options:
 
 	// Start the server
 	log.Fatal(http.ListenAndServe(":8080", router))
+
+OPTIONS requests are answered by the middleware itself (the preflight short-circuit)
+unless the request was dispatched to an explicitly registered OPTIONS route. In that
+case the CORS headers are still set, but the route's handler writes the response.
 */
 func CORS(options ...CORSOption) func(http.Handler) http.Handler {
-	cfg := &corsConfig{
-		AllowedHeaders:   make(map[string]string),
-		PreflightHeaders: make(map[string]string),
-	}
-
-	for _, option := range options {
-		option(cfg)
-	}
+	cfg := newCORSConfig(options...)
 
 	return func(h http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			// The response depends on the Origin, whether it is allowed or not
+			addVary(w.Header(), "Origin")
+
 			origin := r.Header.Get("Origin")
-			if origin != "" && contains(cfg.AllowedOrigins, origin) {
+			if origin != "" && cfg.allowsOrigin(origin) {
 				w.Header().Set("Access-Control-Allow-Origin", origin)
-			} else {
+				if cfg.AllowCredentials {
+					w.Header().Set("Access-Control-Allow-Credentials", "true")
+				}
+			} else if !cfg.AllowCredentials {
 				// Always set the Access-Control-Allow-Origin header, even if the
-				// incoming request does not contain an "Origin" header.
+				// incoming request does not contain an "Origin" header. With
+				// credentials allowed, a wildcard would be rejected by browsers.
 				w.Header().Set("Access-Control-Allow-Origin", "*")
 			}
 
@@ -148,34 +244,179 @@ func CORS(options ...CORSOption) func(http.Handler) http.Handler {
 			}
 
 			if len(cfg.AllowedHeaders) > 0 {
-				allowedHeaders := strings.Join(keys(cfg.AllowedHeaders), ", ")
+				allowedHeaders := strings.Join(cfg.AllowedHeaderOrder, ", ")
 				w.Header().Set("Access-Control-Allow-Headers", allowedHeaders)
 			}
 
-			if r.Method == http.MethodOptions {
+			if r.Method == http.MethodOptions && !isExplicitOptions(r) {
+				if len(cfg.AllowedHeaders) == 0 && cfg.ReflectRequestHeaders {
+					addVary(w.Header(), "Access-Control-Request-Headers")
+					if requested := r.Header.Get("Access-Control-Request-Headers"); requested != "" {
+						w.Header().Set("Access-Control-Allow-Headers", requested)
+					}
+				}
 				if cfg.MaxAge > 0 {
 					w.Header().Set("Access-Control-Max-Age", strconv.FormatInt(int64(cfg.MaxAge), 10))
 				}
 				for k, v := range cfg.PreflightHeaders {
 					w.Header().Set(k, v)
 				}
-				w.WriteHeader(http.StatusOK)
+				status := cfg.PreflightStatus
+				if status == 0 {
+					status = http.StatusOK
+				}
+				w.WriteHeader(status)
 				return
 			}
+
+			if len(cfg.ExposedHeaders) > 0 {
+				w.Header().Set("Access-Control-Expose-Headers", strings.Join(cfg.ExposedHeaders, ", "))
+			}
 			h.ServeHTTP(w, r)
 		})
 	}
 }
 
-// keys returns the keys of the given map as a string slice.
-func keys(m map[string]string) []string {
+// newCORSConfig returns a corsConfig with the given options applied.
+func newCORSConfig(options ...CORSOption) *corsConfig {
+	cfg := &corsConfig{
+		AllowedHeaders:   make(map[string]string),
+		PreflightHeaders: make(map[string]string),
+	}
+
+	for _, option := range options {
+		option(cfg)
+	}
+
+	return cfg
+}
+
+/*
+CORSConfig accumulates CORSOption values so a base CORS configuration can be shared
+across routers, cloned, and tweaked before being turned into middleware.
+
+Usage:
+
+	base := middleware.NewCORSConfig(
+		middleware.WithAllowedOrigins("https://example.com"),
+		middleware.WithAllowedMethods("GET", "POST"),
+	)
+
+	// The admin router additionally allows DELETE without affecting base
+	admin := base.Clone().With(middleware.WithAllowedMethods("GET", "POST", "DELETE"))
+
+	api.Use(base.Middleware())
+	adminRouter.Use(admin.Middleware())
+*/
+type CORSConfig struct {
+	options []CORSOption
+}
+
+// NewCORSConfig creates a CORSConfig holding the given options.
+func NewCORSConfig(options ...CORSOption) *CORSConfig {
+	return &CORSConfig{options: append([]CORSOption{}, options...)}
+}
+
+// With appends options to the config and returns it. Later options override earlier ones.
+func (c *CORSConfig) With(options ...CORSOption) *CORSConfig {
+	c.options = append(c.options, options...)
+	return c
+}
+
+// Clone returns an independent copy of the config. Options added to the clone do not
+// affect the original, and vice versa.
+func (c *CORSConfig) Clone() *CORSConfig {
+	return NewCORSConfig(c.options...)
+}
+
+// Middleware returns the CORS middleware for the accumulated options.
+func (c *CORSConfig) Middleware() func(http.Handler) http.Handler {
+	return CORS(c.options...)
+}
+
+// AllowedOrigins returns the allowed origins resulting from the accumulated options.
+func (c *CORSConfig) AllowedOrigins() []string {
+	return newCORSConfig(c.options...).AllowedOrigins
+}
+
+// AllowedMethods returns the allowed methods resulting from the accumulated options.
+func (c *CORSConfig) AllowedMethods() []string {
+	return newCORSConfig(c.options...).AllowedMethods
+}
+
+// AllowedHeaders returns the allowed headers resulting from the accumulated options,
+// in the order they were added.
+func (c *CORSConfig) AllowedHeaders() []string {
+	return newCORSConfig(c.options...).AllowedHeaderOrder
+}
+
+// ExposedHeaders returns the exposed headers resulting from the accumulated options.
+func (c *CORSConfig) ExposedHeaders() []string {
+	return newCORSConfig(c.options...).ExposedHeaders
+}
+
+// MaxAge returns the preflight max age resulting from the accumulated options.
+func (c *CORSConfig) MaxAge() int {
+	return newCORSConfig(c.options...).MaxAge
+}
+
+// sortedKeys returns the keys of the given map as a sorted string slice.
+func sortedKeys(m map[string]string) []string {
 	keys := make([]string, 0, len(m))
 	for k := range m {
 		keys = append(keys, k)
 	}
+	sort.Strings(keys)
 	return keys
 }
 
+// allowsOrigin reports whether origin is one of the allowed origins, matches one of
+// their wildcard subdomain patterns, or is accepted by AllowOriginFunc.
+func (cfg *corsConfig) allowsOrigin(origin string) bool {
+	if contains(cfg.AllowedOrigins, origin) {
+		return true
+	}
+	for _, pattern := range cfg.AllowedOrigins {
+		if strings.Contains(pattern, "*.") && matchOriginPattern(pattern, origin) {
+			return true
+		}
+	}
+	return cfg.AllowOriginFunc != nil && cfg.AllowOriginFunc(origin)
+}
+
+// matchOriginPattern reports whether origin matches a pattern like
+// "https://*.example.com", where "*" stands for one or more subdomain labels. A
+// pattern without a scheme matches origins of any scheme.
+func matchOriginPattern(pattern, origin string) bool {
+	pattern, origin = strings.ToLower(pattern), strings.ToLower(origin)
+	i := strings.Index(pattern, "*.")
+	prefix, suffix := pattern[:i], pattern[i+1:]
+	if prefix == "" {
+		if j := strings.Index(origin, "://"); j >= 0 {
+			origin = origin[j+len("://"):]
+		}
+	}
+
+	if len(origin) <= len(prefix)+len(suffix) || !strings.HasPrefix(origin, prefix) || !strings.HasSuffix(origin, suffix) {
+		return false
+	}
+	subdomain := origin[len(prefix) : len(origin)-len(suffix)]
+	return !strings.ContainsAny(subdomain, "/:@")
+}
+
+// addVary adds name to the Vary header unless it is listed already, keeping the
+// values set by other handlers and middleware.
+func addVary(h http.Header, name string) {
+	for _, value := range h.Values("Vary") {
+		for _, field := range strings.Split(value, ",") {
+			if field = strings.TrimSpace(field); field == "*" || strings.EqualFold(field, name) {
+				return
+			}
+		}
+	}
+	h.Add("Vary", name)
+}
+
 // contains checks if the given string slice contains the given string.
 func contains(slice []string, s string) bool {
 	for _, elem := range slice {
@@ -3,6 +3,7 @@ package middleware
 import (
 	"net/http"
 	"net/http/httptest"
+	"reflect"
 	"strings"
 	"testing"
 )
@@ -97,6 +98,33 @@ func TestCORS(t *testing.T) {
 				"X-Preflight-Header":           []string{"123"},
 			},
 		},
+		{
+			name:   "Simple request with exposed headers",
+			method: http.MethodGet,
+			origin: "http://example.com",
+			config: []CORSOption{
+				WithAllowedOrigins("http://example.com"),
+				WithExposedHeaders("X-Custom-Header", "X-Request-ID"),
+			},
+			expectedStatusCode: http.StatusOK,
+			expectedHeaders: http.Header{
+				"Access-Control-Allow-Origin":   []string{"http://example.com"},
+				"Access-Control-Expose-Headers": []string{"X-Custom-Header", "X-Request-ID"},
+			},
+		},
+		{
+			name:   "Simple request without exposed headers",
+			method: http.MethodGet,
+			origin: "http://example.com",
+			config: []CORSOption{
+				WithAllowedOrigins("http://example.com"),
+			},
+			expectedStatusCode: http.StatusOK,
+			expectedHeaders: http.Header{
+				"Access-Control-Allow-Origin":   []string{"http://example.com"},
+				"Access-Control-Expose-Headers": nil,
+			},
+		},
 	}
 
 	for _, tc := range tests {
@@ -131,3 +159,323 @@ func TestCORS(t *testing.T) {
 		})
 	}
 }
+
+func TestCORS_ExplicitOptions(t *testing.T) {
+	req := httptest.NewRequest(http.MethodOptions, "http://example.com", nil)
+	req = req.WithContext(WithExplicitOptions(req.Context()))
+	req.Header.Set("Origin", "http://example.com")
+
+	rr := httptest.NewRecorder()
+	handler := CORS(WithAllowedOrigins("http://example.com"))(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusAccepted)
+		w.Write([]byte("capabilities")) // nolint: errcheck
+	}))
+	handler.ServeHTTP(rr, req)
+
+	if rr.Code != http.StatusAccepted {
+		t.Errorf("expected status code %d, got %d", http.StatusAccepted, rr.Code)
+	}
+	if rr.Body.String() != "capabilities" {
+		t.Errorf("expected body %q, got %q", "capabilities", rr.Body.String())
+	}
+	if got := rr.Header().Get("Access-Control-Allow-Origin"); got != "http://example.com" {
+		t.Errorf("expected Access-Control-Allow-Origin %q, got %q", "http://example.com", got)
+	}
+}
+
+func TestCORSConfig_Clone(t *testing.T) {
+	base := NewCORSConfig(
+		WithAllowedOrigins("http://example.com"),
+		WithAllowedMethods(http.MethodGet),
+		WithAllowedHeaders("Content-Type"),
+		WithMaxAge(600),
+	)
+
+	clone := base.Clone().With(WithAllowedMethods(http.MethodGet, http.MethodDelete))
+	base.With(WithMaxAge(1200))
+
+	if got := base.AllowedMethods(); !reflect.DeepEqual(got, []string{http.MethodGet}) {
+		t.Errorf("expected base methods %v, got %v", []string{http.MethodGet}, got)
+	}
+	if got := clone.AllowedMethods(); !reflect.DeepEqual(got, []string{http.MethodGet, http.MethodDelete}) {
+		t.Errorf("expected clone methods %v, got %v", []string{http.MethodGet, http.MethodDelete}, got)
+	}
+	if base.MaxAge() != 1200 || clone.MaxAge() != 600 {
+		t.Errorf("expected max age 1200/600, got %d/%d", base.MaxAge(), clone.MaxAge())
+	}
+	if got := clone.AllowedOrigins(); !reflect.DeepEqual(got, []string{"http://example.com"}) {
+		t.Errorf("expected clone to keep origins, got %v", got)
+	}
+	if got := clone.AllowedHeaders(); !reflect.DeepEqual(got, []string{"Content-Type"}) {
+		t.Errorf("expected clone to keep headers, got %v", got)
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "http://example.com", nil)
+	req.Header.Set("Origin", "http://example.com")
+	rr := httptest.NewRecorder()
+	clone.Middleware()(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {})).ServeHTTP(rr, req)
+
+	expected := http.MethodGet + ", " + http.MethodDelete
+	if got := rr.Header().Get("Access-Control-Allow-Methods"); got != expected {
+		t.Errorf("expected Access-Control-Allow-Methods %q, got %q", expected, got)
+	}
+}
+
+func TestCORS_AllowCredentials(t *testing.T) {
+	tests := []struct {
+		name                string
+		origin              string
+		options             []CORSOption
+		expectedOrigin      string
+		expectedCredentials string
+	}{
+		{
+			name:                "allowed origin is echoed with credentials",
+			origin:              "http://example.com",
+			options:             []CORSOption{WithAllowedOrigins("http://example.com"), WithAllowCredentials()},
+			expectedOrigin:      "http://example.com",
+			expectedCredentials: "true",
+		},
+		{
+			name:    "disallowed origin gets no wildcard with credentials",
+			origin:  "http://evil.com",
+			options: []CORSOption{WithAllowedOrigins("http://example.com"), WithAllowCredentials()},
+		},
+		{
+			name:    "missing origin gets no wildcard with credentials",
+			options: []CORSOption{WithAllowedOrigins("http://example.com"), WithAllowCredentials()},
+		},
+		{
+			name:           "disallowed origin gets wildcard without credentials",
+			origin:         "http://evil.com",
+			options:        []CORSOption{WithAllowedOrigins("http://example.com")},
+			expectedOrigin: "*",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "http://example.com", nil)
+			if tc.origin != "" {
+				req.Header.Set("Origin", tc.origin)
+			}
+
+			rr := httptest.NewRecorder()
+			CORS(tc.options...)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {})).ServeHTTP(rr, req)
+
+			if got := rr.Header().Get("Access-Control-Allow-Origin"); got != tc.expectedOrigin {
+				t.Errorf("expected Access-Control-Allow-Origin %q, got %q", tc.expectedOrigin, got)
+			}
+			if got := rr.Header().Get("Access-Control-Allow-Credentials"); got != tc.expectedCredentials {
+				t.Errorf("expected Access-Control-Allow-Credentials %q, got %q", tc.expectedCredentials, got)
+			}
+		})
+	}
+}
+
+func TestCORS_WildcardSubdomains(t *testing.T) {
+	handler := CORS(
+		WithAllowedOrigins("https://example.com", "https://*.example.com", "*.example.org"),
+		WithAllowCredentials(),
+	)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+
+	tests := []struct {
+		origin  string
+		allowed bool
+	}{
+		{"https://example.com", true},
+		{"https://api.example.com", true},
+		{"https://a.b.example.com", true},
+		{"https://API.Example.com", true},
+		{"http://api.example.com", false},
+		{"https://api.example.com:8443", false},
+		{"https://evilexample.com", false},
+		{"https://example.com.evil.com", false},
+		{"https://.example.com", false},
+		{"http://www.example.org", true},
+		{"https://www.example.org", true},
+		{"https://example.org", false},
+	}
+
+	for _, tc := range tests {
+		req := httptest.NewRequest(http.MethodGet, "http://example.com", nil)
+		req.Header.Set("Origin", tc.origin)
+		rr := httptest.NewRecorder()
+		handler.ServeHTTP(rr, req)
+
+		expected := ""
+		if tc.allowed {
+			expected = tc.origin
+		}
+		if got := rr.Header().Get("Access-Control-Allow-Origin"); got != expected {
+			t.Errorf("origin %q: expected Access-Control-Allow-Origin %q, got %q", tc.origin, expected, got)
+		}
+	}
+}
+
+func TestCORS_AllowOriginFunc(t *testing.T) {
+	var consulted []string
+	handler := CORS(
+		WithAllowedOrigins("https://example.com"),
+		WithAllowOriginFunc(func(origin string) bool {
+			consulted = append(consulted, origin)
+			return strings.HasSuffix(origin, ".partner.test")
+		}),
+	)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+
+	tests := []struct {
+		origin         string
+		expectedOrigin string
+	}{
+		{"https://example.com", "https://example.com"},
+		{"https://acme.partner.test", "https://acme.partner.test"},
+		{"https://evil.test", "*"},
+	}
+
+	for _, tc := range tests {
+		req := httptest.NewRequest(http.MethodGet, "http://example.com", nil)
+		req.Header.Set("Origin", tc.origin)
+		rr := httptest.NewRecorder()
+		handler.ServeHTTP(rr, req)
+
+		if got := rr.Header().Get("Access-Control-Allow-Origin"); got != tc.expectedOrigin {
+			t.Errorf("origin %q: expected Access-Control-Allow-Origin %q, got %q", tc.origin, tc.expectedOrigin, got)
+		}
+	}
+
+	expected := []string{"https://acme.partner.test", "https://evil.test"}
+	if !reflect.DeepEqual(consulted, expected) {
+		t.Errorf("expected the function to be consulted for %v, got %v", expected, consulted)
+	}
+}
+
+func TestCORS_ReflectRequestHeaders(t *testing.T) {
+	tests := []struct {
+		name            string
+		options         []CORSOption
+		method          string
+		requested       string
+		expectedHeaders string
+	}{
+		{
+			name:            "requested headers are reflected",
+			options:         []CORSOption{WithReflectRequestHeaders()},
+			method:          http.MethodOptions,
+			requested:       "X-Api-Key, Content-Type",
+			expectedHeaders: "X-Api-Key, Content-Type",
+		},
+		{
+			name:            "configured headers take precedence",
+			options:         []CORSOption{WithReflectRequestHeaders(), WithAllowedHeaders("Authorization")},
+			method:          http.MethodOptions,
+			requested:       "X-Api-Key",
+			expectedHeaders: "Authorization",
+		},
+		{
+			name:      "not reflected by default",
+			method:    http.MethodOptions,
+			requested: "X-Api-Key",
+		},
+		{
+			name:      "not reflected outside preflight",
+			options:   []CORSOption{WithReflectRequestHeaders()},
+			method:    http.MethodGet,
+			requested: "X-Api-Key",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			req := httptest.NewRequest(tc.method, "http://example.com", nil)
+			req.Header.Set("Origin", "http://example.com")
+			req.Header.Set("Access-Control-Request-Headers", tc.requested)
+
+			rr := httptest.NewRecorder()
+			CORS(tc.options...)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {})).ServeHTTP(rr, req)
+
+			if got := rr.Header().Get("Access-Control-Allow-Headers"); got != tc.expectedHeaders {
+				t.Errorf("expected Access-Control-Allow-Headers %q, got %q", tc.expectedHeaders, got)
+			}
+		})
+	}
+}
+
+func TestCORS_VaryOrigin(t *testing.T) {
+	tests := []struct {
+		name         string
+		method       string
+		origin       string
+		existingVary []string
+		expectedVary []string
+	}{
+		{"allowed origin", http.MethodGet, "http://example.com", nil, []string{"Origin"}},
+		{"disallowed origin", http.MethodGet, "http://evil.com", nil, []string{"Origin"}},
+		{"missing origin", http.MethodGet, "", nil, []string{"Origin"}},
+		{"preflight", http.MethodOptions, "http://example.com", nil, []string{"Origin"}},
+		{"merged with existing Vary", http.MethodGet, "http://example.com", []string{"Accept-Language"}, []string{"Accept-Language", "Origin"}},
+		{"not duplicated", http.MethodGet, "http://example.com", []string{"Accept-Language, origin"}, []string{"Accept-Language, origin"}},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			req := httptest.NewRequest(tc.method, "http://example.com", nil)
+			if tc.origin != "" {
+				req.Header.Set("Origin", tc.origin)
+			}
+
+			rr := httptest.NewRecorder()
+			for _, value := range tc.existingVary {
+				rr.Header().Add("Vary", value)
+			}
+			CORS(WithAllowedOrigins("http://example.com"))(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {})).ServeHTTP(rr, req)
+
+			if got := rr.Header().Values("Vary"); !reflect.DeepEqual(got, tc.expectedVary) {
+				t.Errorf("expected Vary %v, got %v", tc.expectedVary, got)
+			}
+		})
+	}
+}
+
+func TestCORS_VaryWithGzip(t *testing.T) {
+	handler := CORS(WithAllowedOrigins("http://example.com"))(Gzip(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {})))
+
+	req := httptest.NewRequest(http.MethodGet, "http://example.com", nil)
+	req.Header.Set("Origin", "http://example.com")
+	req.Header.Set("Accept-Encoding", "gzip")
+	rr := httptest.NewRecorder()
+	handler.ServeHTTP(rr, req)
+
+	expected := []string{"Origin", "Accept-Encoding"}
+	if got := rr.Header().Values("Vary"); !reflect.DeepEqual(got, expected) {
+		t.Errorf("expected Vary %v, got %v", expected, got)
+	}
+}
+
+func TestCORS_PreflightStatus(t *testing.T) {
+	tests := []struct {
+		name         string
+		options      []CORSOption
+		expectedCode int
+	}{
+		{"legacy default", nil, http.StatusOK},
+		{"no content", []CORSOption{WithPreflightStatus(http.StatusNoContent)}, http.StatusNoContent},
+		{"zero selects no content", []CORSOption{WithPreflightStatus(0)}, http.StatusNoContent},
+		{"custom status", []CORSOption{WithPreflightStatus(http.StatusOK)}, http.StatusOK},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodOptions, "http://example.com", nil)
+			req.Header.Set("Origin", "http://example.com")
+
+			rr := httptest.NewRecorder()
+			CORS(tc.options...)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				t.Error("expected the preflight to be answered by the middleware")
+			})).ServeHTTP(rr, req)
+
+			if rr.Code != tc.expectedCode {
+				t.Errorf("expected status code %d, got %d", tc.expectedCode, rr.Code)
+			}
+		})
+	}
+}
@@ -0,0 +1,36 @@
+package middleware
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// DeadlineHeader is the request header PropagateDeadline writes the remaining time to.
+const DeadlineHeader = "X-Request-Timeout-Ms"
+
+/*
+PropagateDeadline is a middleware that sets the DeadlineHeader of requests whose
+context has a deadline, for example from a timeout middleware registered before
+it, to the remaining time in milliseconds. Handlers proxying or copying the request
+headers to downstream services pass the budget on, so those can give up in time.
+A deadline that has already passed is reported as 0. Requests without a deadline
+are passed through unchanged.
+
+Usage:
+
+	r := muxer.NewRouter()
+	r.Use(timeout(5*time.Second), middleware.PropagateDeadline)
+*/
+func PropagateDeadline(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if deadline, ok := r.Context().Deadline(); ok {
+			remaining := time.Until(deadline).Milliseconds()
+			if remaining < 0 {
+				remaining = 0
+			}
+			r.Header.Set(DeadlineHeader, strconv.FormatInt(remaining, 10))
+		}
+		next.ServeHTTP(w, r)
+	})
+}
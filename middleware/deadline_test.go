@@ -0,0 +1,41 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+)
+
+func TestPropagateDeadline(t *testing.T) {
+	var header string
+	handler := PropagateDeadline(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		header = r.Header.Get(DeadlineHeader)
+	}))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil).WithContext(ctx))
+
+	remaining, err := strconv.Atoi(header)
+	if err != nil {
+		t.Fatalf("expected a numeric %s header, got %q", DeadlineHeader, header)
+	}
+	if remaining <= 1000 || remaining > 2000 {
+		t.Errorf("expected remaining time in (1000, 2000] ms, got %d", remaining)
+	}
+
+	expired, cancelExpired := context.WithDeadline(context.Background(), time.Now().Add(-time.Second))
+	defer cancelExpired()
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil).WithContext(expired))
+	if header != "0" {
+		t.Errorf("expected %s header %q for an expired deadline, got %q", DeadlineHeader, "0", header)
+	}
+
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+	if header != "" {
+		t.Errorf("expected no %s header without a deadline, got %q", DeadlineHeader, header)
+	}
+}
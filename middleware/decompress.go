@@ -0,0 +1,143 @@
+package middleware
+
+import (
+	"compress/gzip"
+	"compress/zlib"
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+)
+
+// decompressionKey is the context key under which the decompression sizes are stored.
+const decompressionKey contextKey = "decompression"
+
+// ErrDecompressedBodyTooLarge is returned when reading a request body decompressed
+// by Decompress exceeds its size limit.
+var ErrDecompressedBodyTooLarge = errors.New("middleware: decompressed request body too large")
+
+// decompressionSizes holds the byte counts of a decompressed request body. They are
+// set once the body has been read to the end.
+type decompressionSizes struct {
+	compressed   int64
+	decompressed int64
+}
+
+/*
+Decompress is a middleware that transparently decompresses request bodies sent with
+a gzip or deflate Content-Encoding, so handlers read the plain payload. The
+Content-Encoding header is removed and the content length becomes unknown. Bodies
+with other encodings are passed through; combine with AllowedRequestEncodings to
+reject them.
+
+If maxSize is positive, reading more than maxSize decompressed bytes fails with
+ErrDecompressedBodyTooLarge, which guards against compression bombs.
+
+Once the body has been read to the end, CompressedSize and DecompressedSize report
+the size of the payload on the wire and after decompression.
+
+Usage:
+
+	r := muxer.NewRouter()
+	r.Use(middleware.AllowedRequestEncodings(), middleware.Decompress(10<<20))
+*/
+func Decompress(maxSize int64) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			encoding := strings.ToLower(strings.TrimSpace(r.Header.Get("Content-Encoding")))
+			if !hasBody(r) || (encoding != "gzip" && encoding != "deflate") {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			compressed := &countingReader{Reader: r.Body}
+			var reader io.ReadCloser
+			var err error
+			if encoding == "gzip" {
+				reader, err = gzip.NewReader(compressed)
+			} else {
+				// The HTTP deflate coding is the zlib format, not raw DEFLATE
+				reader, err = zlib.NewReader(compressed)
+			}
+			if err != nil {
+				http.Error(w, "Bad Request", http.StatusBadRequest)
+				return
+			}
+
+			sizes := &decompressionSizes{}
+			body := &decompressingBody{
+				reader:     reader,
+				original:   r.Body,
+				compressed: compressed,
+				sizes:      sizes,
+				max:        maxSize,
+			}
+
+			r.Header.Del("Content-Encoding")
+			r.Header.Del("Content-Length")
+			r.ContentLength = -1
+			r.Body = body
+			next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), decompressionKey, sizes)))
+		})
+	}
+}
+
+// CompressedSize returns the number of compressed bytes of a request body read
+// through Decompress. It is 0 until the body has been read to the end.
+func CompressedSize(r *http.Request) int64 {
+	if sizes, ok := r.Context().Value(decompressionKey).(*decompressionSizes); ok {
+		return sizes.compressed
+	}
+	return 0
+}
+
+// DecompressedSize returns the number of decompressed bytes of a request body read
+// through Decompress. It is 0 until the body has been read to the end.
+func DecompressedSize(r *http.Request) int64 {
+	if sizes, ok := r.Context().Value(decompressionKey).(*decompressionSizes); ok {
+		return sizes.decompressed
+	}
+	return 0
+}
+
+// countingReader counts the bytes read from the wrapped reader.
+type countingReader struct {
+	io.Reader
+	n int64
+}
+
+func (c *countingReader) Read(p []byte) (int, error) {
+	n, err := c.Reader.Read(p)
+	c.n += int64(n)
+	return n, err
+}
+
+// decompressingBody is a request body that decompresses the original body, enforces
+// the size limit and records the sizes once the end is reached.
+type decompressingBody struct {
+	reader     io.ReadCloser
+	original   io.ReadCloser
+	compressed *countingReader
+	sizes      *decompressionSizes
+	n          int64
+	max        int64
+}
+
+func (b *decompressingBody) Read(p []byte) (int, error) {
+	n, err := b.reader.Read(p)
+	b.n += int64(n)
+	if b.max > 0 && b.n > b.max {
+		return n, ErrDecompressedBodyTooLarge
+	}
+	if err == io.EOF {
+		b.sizes.compressed = b.compressed.n
+		b.sizes.decompressed = b.n
+	}
+	return n, err
+}
+
+func (b *decompressingBody) Close() error {
+	b.reader.Close() // nolint: errcheck
+	return b.original.Close()
+}
@@ -0,0 +1,138 @@
+package middleware
+
+import (
+	"bytes"
+	"compress/gzip"
+	"compress/zlib"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func gzipBytes(t *testing.T, data []byte) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	if _, err := gz.Write(data); err != nil {
+		t.Fatalf("failed to compress: %v", err)
+	}
+	if err := gz.Close(); err != nil {
+		t.Fatalf("failed to compress: %v", err)
+	}
+	return buf.Bytes()
+}
+
+func TestDecompress(t *testing.T) {
+	payload := []byte(strings.Repeat("compressible payload ", 100))
+	compressed := gzipBytes(t, payload)
+
+	var body []byte
+	var compressedSize, decompressedSize int64
+	handler := Decompress(0)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if got := CompressedSize(r); got != 0 {
+			t.Errorf("expected compressed size 0 before reading, got %d", got)
+		}
+		if r.Header.Get("Content-Encoding") != "" {
+			t.Errorf("expected Content-Encoding to be removed")
+		}
+		body, _ = io.ReadAll(r.Body)
+		compressedSize, decompressedSize = CompressedSize(r), DecompressedSize(r)
+	}))
+
+	req := httptest.NewRequest(http.MethodPost, "/", bytes.NewReader(compressed))
+	req.Header.Set("Content-Encoding", "gzip")
+	handler.ServeHTTP(httptest.NewRecorder(), req)
+
+	if !bytes.Equal(body, payload) {
+		t.Errorf("expected decompressed body of %d bytes, got %d", len(payload), len(body))
+	}
+	if compressedSize != int64(len(compressed)) {
+		t.Errorf("expected compressed size %d, got %d", len(compressed), compressedSize)
+	}
+	if decompressedSize != int64(len(payload)) {
+		t.Errorf("expected decompressed size %d, got %d", len(payload), decompressedSize)
+	}
+}
+
+func TestDecompress_Deflate(t *testing.T) {
+	payload := []byte(strings.Repeat("compressible payload ", 100))
+	var buf bytes.Buffer
+	zw := zlib.NewWriter(&buf)
+	if _, err := zw.Write(payload); err != nil {
+		t.Fatalf("failed to compress: %v", err)
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatalf("failed to compress: %v", err)
+	}
+
+	var body []byte
+	var err error
+	handler := Decompress(0)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, err = io.ReadAll(r.Body)
+	}))
+
+	req := httptest.NewRequest(http.MethodPost, "/", bytes.NewReader(buf.Bytes()))
+	req.Header.Set("Content-Encoding", "deflate")
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected status code %d, got %d", http.StatusOK, rec.Code)
+	}
+	if err != nil {
+		t.Fatalf("failed to read body: %v", err)
+	}
+	if !bytes.Equal(body, payload) {
+		t.Errorf("expected decompressed body of %d bytes, got %d", len(payload), len(body))
+	}
+}
+
+func TestDecompress_MaxSize(t *testing.T) {
+	compressed := gzipBytes(t, bytes.Repeat([]byte{0}, 1<<20))
+
+	var err error
+	handler := Decompress(1024)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, err = io.ReadAll(r.Body)
+	}))
+
+	req := httptest.NewRequest(http.MethodPost, "/", bytes.NewReader(compressed))
+	req.Header.Set("Content-Encoding", "gzip")
+	handler.ServeHTTP(httptest.NewRecorder(), req)
+
+	if !errors.Is(err, ErrDecompressedBodyTooLarge) {
+		t.Errorf("expected ErrDecompressedBodyTooLarge, got %v", err)
+	}
+}
+
+func TestDecompress_Passthrough(t *testing.T) {
+	var body string
+	handler := Decompress(0)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		b, _ := io.ReadAll(r.Body)
+		body = string(b)
+		if DecompressedSize(r) != 0 {
+			t.Errorf("expected no decompression sizes for a plain body")
+		}
+	}))
+
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodPost, "/", strings.NewReader("plain")))
+
+	if body != "plain" {
+		t.Errorf("expected body %q, got %q", "plain", body)
+	}
+}
+
+func TestDecompress_InvalidGzip(t *testing.T) {
+	handler := Decompress(0)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+
+	req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader("not gzip"))
+	req.Header.Set("Content-Encoding", "gzip")
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusBadRequest {
+		t.Errorf("expected status code %d, got %d", http.StatusBadRequest, rec.Code)
+	}
+}
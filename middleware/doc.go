@@ -5,6 +5,8 @@ CORS middleware adds Cross-Origin Resource Sharing (CORS) headers to the HTTP re
 
 The middleware can be customized by passing in one or more CORSOption values to the constructor. These options can be used to configure the allowed origins, methods, headers, and other CORS settings.
 
+A CORSConfig accumulates CORSOption values so a base configuration can be shared across routers and cloned before being tweaked. Call Middleware to turn it into CORS middleware.
+
 Usage:
 
 		// Create a new Router
@@ -25,6 +27,8 @@ Gzip middleware returns a new HTTP handler function that compresses the response
 
 If the client doesn't support gzip encoding, it just calls the next handler in the chain without modifying the response.
 
+GzipLevel returns the same middleware compressing at a given level, such as gzip.BestSpeed. Bodies smaller than DefaultGzipMinSize (1400 bytes) are sent uncompressed; pass WithGzipMinSize to GzipLevel to change the threshold.
+
 Example usage:
 
 	 r := muxer.NewRouter()
@@ -51,6 +55,186 @@ Usage:
 	})
 	http.ListenAndServe(":1123", r)
 
-The RecoveryHandler logs errors and, if printStack is true, also logs a stack trace. If printStack is false, no stack trace is logged. If no logger is provided, it uses the default Go logger.
+The RecoveryHandler logs errors and, if printStack is true, also logs a stack trace. If printStack is false, no stack trace is logged. If no logger is provided, it uses the default Go logger. If the logger implements StructuredLogger, such as the SlogLogger adapter for log/slog, the panic is logged as a structured record with error, method, path and stack attributes.
+
+The 500 response is empty by default. Pass WithPanicRenderer, for example with JSONPanicRenderer, to render a body instead; the same option can be given to the router's WithRecovery so panics are rendered uniformly. Panic values with a StatusCode() int method returning a 4xx or 5xx status, such as muxer.HTTPError, are answered with that status instead; WithPanicClassifier replaces this classification.
+
+	-------------------------------------------------------------------------
+
+MapStatus middleware rewrites response status codes according to a mapping before they reach the client, for example to turn 204 No Content into 200 OK for legacy clients. A handler that never calls WriteHeader is treated as writing 200.
+
+Usage:
+
+	r := muxer.NewRouter()
+	r.Use(middleware.MapStatus(map[int]int{http.StatusNoContent: http.StatusOK}))
+
+	 -------------------------------------------------------------------------
+
+StripHopByHop middleware removes hop-by-hop headers (Connection, Keep-Alive, Proxy-Authenticate, TE, Trailer, Transfer-Encoding, Upgrade and any header named in Connection) from incoming requests, as RFC 7230 requires of proxies.
+
+Usage:
+
+	r := muxer.NewRouter()
+	r.Use(middleware.StripHopByHop)
+
+	 -------------------------------------------------------------------------
+
+DurationTrailer middleware sends the total handler duration as an X-Duration HTTP trailer on responses that support trailers (HTTP/1.1 chunked or HTTP/2).
+
+Usage:
+
+	r := muxer.NewRouter()
+	r.Use(middleware.DurationTrailer)
+
+	 -------------------------------------------------------------------------
+
+AllowedRequestEncodings middleware rejects request bodies whose Content-Encoding is not in an allow-list (default: identity, gzip) with 415 Unsupported Media Type.
+
+Usage:
+
+	r := muxer.NewRouter()
+	r.Use(middleware.AllowedRequestEncodings("identity", "gzip"))
+
+	 -------------------------------------------------------------------------
+
+RequestID middleware assigns an ID to every request, honoring the first client-supplied ID found in a configurable list of inbound headers (default X-Request-ID) and generating one otherwise. The ID is stored in the request context, readable with GetRequestID, and written to a configurable response header.
+
+Usage:
+
+	r := muxer.NewRouter()
+	r.Use(middleware.RequestID(
+		middleware.WithRequestIDHeaders("X-Request-ID", "X-Correlation-ID"),
+	))
+
+	 -------------------------------------------------------------------------
+
+SanitizePath middleware rejects requests whose path (and optionally query string) contains null bytes or other control characters with 400 Bad Request.
+
+Usage:
+
+	r := muxer.NewRouter()
+	r.Use(middleware.SanitizePath(true))
+
+	 -------------------------------------------------------------------------
+
+EchoHeaders middleware is a diagnostic tool that copies selected request headers into the response with a prefix, e.g. Origin becomes X-Echo-Origin.
+
+Usage:
+
+	r := muxer.NewRouter()
+	r.Use(middleware.EchoHeaders("X-Echo-", "Origin", "X-Forwarded-For"))
+
+	 -------------------------------------------------------------------------
+
+BodyLogger middleware is an opt-in debugging tool that logs request and response bodies to the injected logger, truncated to a configurable size and optionally redacted. The handler still receives the full request body, and responses are written through to the client as they are produced.
+
+Usage:
+
+	r := muxer.NewRouter()
+	r.Use(middleware.BodyLogger(myLogger, middleware.WithBodyLogLimit(512)))
+
+	 -------------------------------------------------------------------------
+
+RequireTLSVersion middleware rejects requests received over a TLS version lower than the given minimum with 426 Upgrade Required. Plaintext requests are passed through unless WithRejectPlaintext is given.
+
+Usage:
+
+	r := muxer.NewRouter()
+	r.Use(middleware.RequireTLSVersion(tls.VersionTLS12))
+
+	 -------------------------------------------------------------------------
+
+ETag middleware buffers successful GET and HEAD responses up to a size cap, sets a weak ETag computed from the body and answers matching If-None-Match requests with 304 Not Modified. HEAD requests get the full headers, including Content-Length and ETag, without a body. Register it after compression middleware so the ETag is computed over the uncompressed body.
+
+Usage:
+
+	r := muxer.NewRouter()
+	r.Use(middleware.Gzip, middleware.ETag(0))
+
+	 -------------------------------------------------------------------------
+
+Decompress middleware transparently decompresses gzip and deflate request bodies, optionally limiting the decompressed size. Once the body has been read, CompressedSize and DecompressedSize report its size on the wire and after decompression.
+
+Usage:
+
+	r := muxer.NewRouter()
+	r.Use(middleware.AllowedRequestEncodings(), middleware.Decompress(10<<20))
+
+	 -------------------------------------------------------------------------
+
+MaxURLLength middleware rejects requests with URLs longer than a limit with 414 URI Too Long, or passes them to a custom handler when one is given.
+
+Usage:
+
+	r := muxer.NewRouter()
+	r.Use(middleware.MaxURLLength(2048, nil))
+
+	 -------------------------------------------------------------------------
+
+Logger middleware writes an access log line with the method, path, status and duration of every request. Handlers can enrich the line with structured fields using AddLogField.
+
+Usage:
+
+	r := muxer.NewRouter()
+	r.Use(middleware.Logger(myLogger))
+
+	r.HandleRoute(http.MethodGet, "/users/:id", func(w http.ResponseWriter, r *http.Request) {
+		middleware.AddLogField(r, "user_id", muxer.Params(r)["id"])
+	})
+
+	 -------------------------------------------------------------------------
+
+Compress middleware compresses response bodies with the content coding negotiated from Accept-Encoding. gzip and deflate are built in and more codings can be registered with WithEncoder. WithEncodingPreference sets the server's preferred order and whether the client's quality values or the server preference decides.
+
+Usage:
+
+	r := muxer.NewRouter()
+	r.Use(middleware.Compress(middleware.WithEncodingPreference([]string{"deflate", "gzip"}, false)))
+
+	 -------------------------------------------------------------------------
+
+PropagateDeadline middleware writes the time remaining until the request context's deadline, in milliseconds, to the X-Request-Timeout-Ms request header, so handlers proxying the request pass the budget on to downstream services.
+
+Usage:
+
+	r := muxer.NewRouter()
+	r.Use(timeout(5*time.Second), middleware.PropagateDeadline)
+
+	 -------------------------------------------------------------------------
+
+DefaultContentType middleware sets a Content-Type on responses whose handler did not set one, so net/http never sniffs it from the body. An explicitly set type always wins.
+
+Usage:
+
+	r := muxer.NewRouter()
+	r.Use(middleware.DefaultContentType("application/json"))
+
+	 -------------------------------------------------------------------------
+
+WrapResponseWriter wraps an http.ResponseWriter in a ResponseRecorder, which records the status code and the number of body bytes written while passing everything through, including Flush, Hijack and Push. It is the building block for middleware that reports on responses.
+
+Usage:
+
+	rec := middleware.WrapResponseWriter(w)
+	next.ServeHTTP(rec, r)
+	log.Printf("%d %d", rec.Status(), rec.BytesWritten())
+
+	 -------------------------------------------------------------------------
+
+IPAllowList middleware rejects requests from IP addresses outside the given addresses and CIDR ranges with 403 Forbidden. The client IP is read with RealIP, which honors an IP recorded in the request context with WithRealIP.
+
+Usage:
+
+	admin := router.Subrouter("/admin")
+	admin.Use(middleware.IPAllowList("10.0.0.0/8", "2001:db8::/32"))
+
+	 -------------------------------------------------------------------------
+
+RateLimit middleware limits each client to a number of requests per second with a token bucket, answering requests over the limit with 429 Too Many Requests and a Retry-After header. Clients are keyed by IP by default; KeyByForwardedIP honors X-Forwarded-For behind a trusted proxy, and any function of the request can be used instead.
+
+Usage:
+
+	r := muxer.NewRouter()
+	r.Use(middleware.RateLimit(10, 20, middleware.KeyByForwardedIP))
 */
 package middleware
@@ -0,0 +1,31 @@
+package middleware
+
+import (
+	"net/http"
+)
+
+/*
+EchoHeaders is a diagnostic middleware that copies the named request headers into
+the response, with each header name prefixed by prefix. It helps debug CORS and
+proxy setups by showing what actually reached the server. Headers absent from the
+request are not echoed.
+
+Usage:
+
+	r := muxer.NewRouter()
+	r.Use(middleware.EchoHeaders("X-Echo-", "Origin", "X-Forwarded-For"))
+	// A request with "Origin: https://example.com" gets "X-Echo-Origin: https://example.com"
+*/
+func EchoHeaders(prefix string, names ...string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			for _, name := range names {
+				for _, value := range r.Header.Values(name) {
+					w.Header().Add(prefix+name, value)
+				}
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
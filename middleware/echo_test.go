@@ -0,0 +1,36 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+)
+
+func TestEchoHeaders(t *testing.T) {
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set("Origin", "https://example.com")
+	req.Header.Add("X-Forwarded-For", "203.0.113.5")
+	req.Header.Add("X-Forwarded-For", "10.0.0.1")
+	req.Header.Set("Authorization", "Bearer secret")
+	rec := httptest.NewRecorder()
+
+	handler := EchoHeaders("X-Echo-", "Origin", "X-Forwarded-For", "X-Missing")(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	handler.ServeHTTP(rec, req)
+
+	expected := map[string][]string{
+		"X-Echo-Origin":          {"https://example.com"},
+		"X-Echo-X-Forwarded-For": {"203.0.113.5", "10.0.0.1"},
+	}
+	for k, v := range expected {
+		if got := rec.Header().Values(k); !reflect.DeepEqual(got, v) {
+			t.Errorf("expected header %s with value %v, got %v", k, v, got)
+		}
+	}
+
+	for _, k := range []string{"X-Echo-X-Missing", "X-Echo-Authorization"} {
+		if got := rec.Header().Get(k); got != "" {
+			t.Errorf("expected header %s not to be echoed, got %q", k, got)
+		}
+	}
+}
@@ -0,0 +1,54 @@
+package middleware
+
+import (
+	"net/http"
+	"strings"
+)
+
+/*
+AllowedRequestEncodings is a middleware that rejects request bodies whose
+Content-Encoding is not in the given allow-list with 415 Unsupported Media Type.
+It guards against decompression-bomb surprises from unexpected encodings.
+
+If no encodings are given, "identity" and "gzip" are allowed. Matching is
+case-insensitive, and every encoding in a multi-valued Content-Encoding header
+must be allowed. Requests without a body are always passed through.
+
+Usage:
+
+	r := muxer.NewRouter()
+	r.Use(middleware.AllowedRequestEncodings("identity", "gzip"))
+*/
+func AllowedRequestEncodings(encodings ...string) func(http.Handler) http.Handler {
+	if len(encodings) == 0 {
+		encodings = []string{"identity", "gzip"}
+	}
+
+	allowed := make(map[string]bool, len(encodings))
+	for _, encoding := range encodings {
+		allowed[strings.ToLower(encoding)] = true
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if hasBody(r) {
+				for _, value := range r.Header.Values("Content-Encoding") {
+					for _, encoding := range strings.Split(value, ",") {
+						encoding = strings.ToLower(strings.TrimSpace(encoding))
+						if encoding != "" && !allowed[encoding] {
+							http.Error(w, "Unsupported Content-Encoding", http.StatusUnsupportedMediaType)
+							return
+						}
+					}
+				}
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// hasBody reports whether the request carries a body, including bodies of unknown length.
+func hasBody(r *http.Request) bool {
+	return r.Body != nil && r.Body != http.NoBody && r.ContentLength != 0
+}
@@ -0,0 +1,74 @@
+package middleware
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestAllowedRequestEncodings(t *testing.T) {
+	tests := []struct {
+		name            string
+		encodings       []string
+		body            io.Reader
+		contentEncoding string
+		expectedCode    int
+	}{
+		{
+			name:            "disallowed br encoding",
+			body:            strings.NewReader("payload"),
+			contentEncoding: "br",
+			expectedCode:    http.StatusUnsupportedMediaType,
+		},
+		{
+			name:            "default allows gzip",
+			body:            strings.NewReader("payload"),
+			contentEncoding: "gzip",
+			expectedCode:    http.StatusOK,
+		},
+		{
+			name:         "no content encoding",
+			body:         strings.NewReader("payload"),
+			expectedCode: http.StatusOK,
+		},
+		{
+			name:            "one disallowed encoding in list",
+			body:            strings.NewReader("payload"),
+			contentEncoding: "gzip, deflate",
+			expectedCode:    http.StatusUnsupportedMediaType,
+		},
+		{
+			name:            "custom allow-list",
+			encodings:       []string{"br"},
+			body:            strings.NewReader("payload"),
+			contentEncoding: "BR",
+			expectedCode:    http.StatusOK,
+		},
+		{
+			name:            "request without body passes",
+			contentEncoding: "br",
+			expectedCode:    http.StatusOK,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, "/", tc.body)
+			if tc.contentEncoding != "" {
+				req.Header.Set("Content-Encoding", tc.contentEncoding)
+			}
+			rec := httptest.NewRecorder()
+
+			handler := AllowedRequestEncodings(tc.encodings...)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(http.StatusOK)
+			}))
+			handler.ServeHTTP(rec, req)
+
+			if rec.Code != tc.expectedCode {
+				t.Errorf("expected status code %d, got %d", tc.expectedCode, rec.Code)
+			}
+		})
+	}
+}
@@ -0,0 +1,149 @@
+package middleware
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// DefaultETagMaxBuffer is the response size up to which ETag buffers responses
+// when no positive limit is given.
+const DefaultETagMaxBuffer = 1 << 20
+
+/*
+ETag returns a middleware that buffers successful GET and HEAD responses of up to
+maxBuffer bytes, computes a weak ETag from the body and answers requests whose
+If-None-Match header matches it with 304 Not Modified. HEAD requests receive the
+headers of the full response, including Content-Length and ETag, without the body.
+An ETag set by the handler is kept.
+
+Responses larger than maxBuffer, or with a status other than 200, are streamed
+through unchanged. A non-positive maxBuffer uses DefaultETagMaxBuffer.
+
+The ETag is computed over the bytes the handler writes. Register ETag after a
+compression middleware so it runs inside it and hashes the uncompressed body; the
+ETag is then the same for compressed and uncompressed responses, which is why it is
+weak. Content-Length is not set when the response has a Content-Encoding.
+
+Usage:
+
+	r := muxer.NewRouter()
+	r.Use(middleware.Gzip, middleware.ETag(0))
+*/
+func ETag(maxBuffer int) func(http.Handler) http.Handler {
+	if maxBuffer <= 0 {
+		maxBuffer = DefaultETagMaxBuffer
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.Method != http.MethodGet && r.Method != http.MethodHead {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			ew := &etagWriter{ResponseWriter: w, max: maxBuffer, head: r.Method == http.MethodHead}
+			next.ServeHTTP(ew, r)
+			ew.finish(r)
+		})
+	}
+}
+
+// etagWriter buffers a response until it is complete or exceeds max bytes, at which
+// point it passes the response through.
+type etagWriter struct {
+	http.ResponseWriter
+	buf         bytes.Buffer
+	status      int
+	max         int
+	head        bool
+	passthrough bool
+}
+
+func (w *etagWriter) WriteHeader(code int) {
+	if w.status != 0 {
+		return
+	}
+	w.status = code
+	if code != http.StatusOK {
+		w.passthrough = true
+		w.ResponseWriter.WriteHeader(code)
+	}
+}
+
+func (w *etagWriter) Write(p []byte) (int, error) {
+	if w.status == 0 {
+		w.WriteHeader(http.StatusOK)
+	}
+	if w.passthrough {
+		if w.head {
+			return len(p), nil
+		}
+		return w.ResponseWriter.Write(p)
+	}
+
+	if w.buf.Len()+len(p) > w.max {
+		// Too large to buffer: send what we have and stream the rest
+		w.passthrough = true
+		w.ResponseWriter.WriteHeader(w.status)
+		if w.head {
+			return len(p), nil
+		}
+		if _, err := w.ResponseWriter.Write(w.buf.Bytes()); err != nil {
+			return 0, err
+		}
+		w.buf.Reset()
+		return w.ResponseWriter.Write(p)
+	}
+	return w.buf.Write(p)
+}
+
+// finish writes the buffered response, or 304 Not Modified if the request's
+// If-None-Match header matches its ETag.
+func (w *etagWriter) finish(r *http.Request) {
+	if w.passthrough {
+		return
+	}
+
+	header := w.Header()
+	etag := header.Get("ETag")
+	if etag == "" {
+		sum := sha256.Sum256(w.buf.Bytes())
+		etag = `W/"` + hex.EncodeToString(sum[:16]) + `"`
+		header.Set("ETag", etag)
+	}
+
+	if etagMatches(r.Header.Get("If-None-Match"), etag) {
+		header.Del("Content-Type")
+		header.Del("Content-Length")
+		w.ResponseWriter.WriteHeader(http.StatusNotModified)
+		return
+	}
+
+	if header.Get("Content-Encoding") == "" {
+		header.Set("Content-Length", strconv.Itoa(w.buf.Len()))
+	}
+	w.ResponseWriter.WriteHeader(http.StatusOK)
+	if !w.head {
+		w.ResponseWriter.Write(w.buf.Bytes()) // nolint: errcheck
+	}
+}
+
+// etagMatches reports whether the If-None-Match header value matches etag, using
+// the weak comparison required for If-None-Match.
+func etagMatches(ifNoneMatch, etag string) bool {
+	if ifNoneMatch == "" {
+		return false
+	}
+	etag = strings.TrimPrefix(etag, "W/")
+	for _, candidate := range strings.Split(ifNoneMatch, ",") {
+		candidate = strings.TrimSpace(candidate)
+		if candidate == "*" || strings.TrimPrefix(candidate, "W/") == etag {
+			return true
+		}
+	}
+	return false
+}
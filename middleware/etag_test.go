@@ -0,0 +1,140 @@
+package middleware
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+)
+
+const etagTestBody = "hello, etag"
+
+func etagTestHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/plain")
+		w.Write([]byte(etagTestBody)) // nolint: errcheck
+	})
+}
+
+func TestETag(t *testing.T) {
+	handler := ETag(0)(etagTestHandler())
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+
+	etag := rec.Header().Get("ETag")
+	if rec.Code != http.StatusOK {
+		t.Errorf("expected status code %d, got %d", http.StatusOK, rec.Code)
+	}
+	if len(etag) < 4 || etag[:3] != `W/"` {
+		t.Fatalf("expected weak ETag, got %q", etag)
+	}
+	if rec.Body.String() != etagTestBody {
+		t.Errorf("expected body %q, got %q", etagTestBody, rec.Body.String())
+	}
+	if got := rec.Header().Get("Content-Length"); got != strconv.Itoa(len(etagTestBody)) {
+		t.Errorf("expected Content-Length %d, got %q", len(etagTestBody), got)
+	}
+
+	tests := []struct {
+		name         string
+		ifNoneMatch  string
+		expectedCode int
+		expectedBody string
+	}{
+		{"matching ETag", etag, http.StatusNotModified, ""},
+		{"matching strong form", etag[2:], http.StatusNotModified, ""},
+		{"matching in list", `"other", ` + etag, http.StatusNotModified, ""},
+		{"wildcard", "*", http.StatusNotModified, ""},
+		{"stale ETag", `W/"stale"`, http.StatusOK, etagTestBody},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			req.Header.Set("If-None-Match", tc.ifNoneMatch)
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, req)
+
+			if rec.Code != tc.expectedCode {
+				t.Errorf("expected status code %d, got %d", tc.expectedCode, rec.Code)
+			}
+			if rec.Body.String() != tc.expectedBody {
+				t.Errorf("expected body %q, got %q", tc.expectedBody, rec.Body.String())
+			}
+			if got := rec.Header().Get("ETag"); got != etag {
+				t.Errorf("expected ETag %q, got %q", etag, got)
+			}
+		})
+	}
+}
+
+func TestETag_Head(t *testing.T) {
+	handler := ETag(0)(etagTestHandler())
+
+	get := httptest.NewRecorder()
+	handler.ServeHTTP(get, httptest.NewRequest(http.MethodGet, "/", nil))
+
+	head := httptest.NewRecorder()
+	handler.ServeHTTP(head, httptest.NewRequest(http.MethodHead, "/", nil))
+
+	if head.Code != http.StatusOK {
+		t.Errorf("expected status code %d, got %d", http.StatusOK, head.Code)
+	}
+	if head.Body.Len() != 0 {
+		t.Errorf("expected empty body, got %q", head.Body.String())
+	}
+	if got := head.Header().Get("Content-Length"); got != strconv.Itoa(len(etagTestBody)) {
+		t.Errorf("expected Content-Length %d, got %q", len(etagTestBody), got)
+	}
+	if got, want := head.Header().Get("ETag"), get.Header().Get("ETag"); got != want {
+		t.Errorf("expected ETag %q, got %q", want, got)
+	}
+}
+
+func TestETag_Compression(t *testing.T) {
+	plain := httptest.NewRecorder()
+	ETag(0)(etagTestHandler()).ServeHTTP(plain, httptest.NewRequest(http.MethodGet, "/", nil))
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set("Accept-Encoding", "gzip")
+	compressed := httptest.NewRecorder()
+	GzipLevel(gzip.DefaultCompression, WithGzipMinSize(0))(ETag(0)(etagTestHandler())).ServeHTTP(compressed, req)
+
+	if got, want := compressed.Header().Get("ETag"), plain.Header().Get("ETag"); got != want {
+		t.Errorf("expected ETag over uncompressed bytes %q, got %q", want, got)
+	}
+	if got := compressed.Header().Get("Content-Length"); got != "" {
+		t.Errorf("expected no Content-Length for compressed response, got %q", got)
+	}
+
+	gz, err := gzip.NewReader(compressed.Body)
+	if err != nil {
+		t.Fatalf("failed to read gzip body: %v", err)
+	}
+	body, _ := io.ReadAll(gz)
+	if string(body) != etagTestBody {
+		t.Errorf("expected body %q, got %q", etagTestBody, body)
+	}
+}
+
+func TestETag_MaxBuffer(t *testing.T) {
+	large := bytes.Repeat([]byte("x"), 64)
+	handler := ETag(16)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write(large[:8]) // nolint: errcheck
+		w.Write(large[8:]) // nolint: errcheck
+	}))
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+
+	if got := rec.Header().Get("ETag"); got != "" {
+		t.Errorf("expected no ETag above the buffer cap, got %q", got)
+	}
+	if !bytes.Equal(rec.Body.Bytes(), large) {
+		t.Errorf("expected full body of %d bytes, got %d", len(large), rec.Body.Len())
+	}
+}
@@ -2,11 +2,85 @@ package middleware
 
 import (
 	"compress/gzip"
+	"errors"
+	"fmt"
 	"io"
 	"net/http"
-	"strings"
+	"sync"
 )
 
+// DefaultGzipMinSize is the default size in bytes a response body must reach to be
+// compressed by Gzip and GzipLevel. Smaller bodies fit in a single TCP segment and
+// gain little or nothing from compression.
+const DefaultGzipMinSize = 1400
+
+// gzipWriterPools holds reusable gzip writers for each compression level, indexed
+// by level - gzip.DefaultCompression.
+var gzipWriterPools [gzip.BestCompression - gzip.DefaultCompression + 1]sync.Pool
+
+// errWriterClosed is returned when writing to a pooled writer after it was closed.
+var errWriterClosed = errors.New("middleware: write to closed gzip writer")
+
+// A pooledGzipWriter is a gzip writer taken from the pool of its level, which it is
+// returned to when closed.
+type pooledGzipWriter struct {
+	*gzip.Writer
+	pool *sync.Pool
+}
+
+// newPooledGzipWriter returns a gzip writer at level compressing into w, reusing a
+// pooled one if available. Each writer is used by a single response at a time.
+func newPooledGzipWriter(w io.Writer, level int) io.WriteCloser {
+	pool := &gzipWriterPools[level-gzip.DefaultCompression]
+	if gw, ok := pool.Get().(*gzip.Writer); ok {
+		gw.Reset(w)
+		return &pooledGzipWriter{Writer: gw, pool: pool}
+	}
+	gw, _ := gzip.NewWriterLevel(w, level)
+	return &pooledGzipWriter{Writer: gw, pool: pool}
+}
+
+func (w *pooledGzipWriter) Write(p []byte) (int, error) {
+	if w.Writer == nil {
+		return 0, errWriterClosed
+	}
+	return w.Writer.Write(p)
+}
+
+// Flush writes any pending compressed data to the underlying writer.
+func (w *pooledGzipWriter) Flush() error {
+	if w.Writer == nil {
+		return errWriterClosed
+	}
+	return w.Writer.Flush()
+}
+
+// Close flushes the compressed data and returns the writer to its pool.
+func (w *pooledGzipWriter) Close() error {
+	if w.Writer == nil {
+		return nil
+	}
+	err := w.Writer.Close()
+	w.pool.Put(w.Writer)
+	w.Writer = nil
+	return err
+}
+
+type gzipConfig struct {
+	MinSize int
+}
+
+// GzipOption is a function that modifies the GzipLevel configuration.
+type GzipOption func(*gzipConfig)
+
+// WithGzipMinSize sets the size in bytes a response body must reach to be
+// compressed. A size of 0 compresses every response with a body.
+func WithGzipMinSize(size int) GzipOption {
+	return func(cfg *gzipConfig) {
+		cfg.MinSize = size
+	}
+}
+
 /*
 Gzip is a middleware function that returns a new HTTP handler function
 that compresses the response body using gzip encoding if the client accepts it.
@@ -14,7 +88,15 @@ It modifies the response headers to include the 'Content-Encoding' and 'Vary'
 headers, and wraps the response writer with a gzip writer to compress the body.
 
 If the client doesn't support gzip encoding, it just calls the next handler
-in the chain without modifying the response.
+in the chain without modifying the response. Quality values in Accept-Encoding are
+honored: "gzip;q=0" and "*;q=0" disable compression, and so does listing identity
+with a higher quality value than gzip, as in "identity, gzip;q=0.5". Responses without a body, such as
+204 No Content, 304 Not Modified or a handler that writes nothing, are not
+given a Content-Encoding either.
+
+Bodies smaller than DefaultGzipMinSize bytes are sent uncompressed. The first bytes
+of every response are buffered until the size is known to reach it; use GzipLevel
+with WithGzipMinSize to change the threshold.
 
 Example usage:
 
@@ -28,28 +110,168 @@ r.Use(muxer.Gzip())
 http.ListenAndServe(":8080", r)
 */
 func Gzip(handler http.Handler) http.Handler {
-	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		if !strings.Contains(r.Header.Get("Accept-Encoding"), "gzip") {
-			handler.ServeHTTP(w, r)
-			return
-		}
-		w.Header().Set("Content-Encoding", "gzip")
-		w.Header().Set("Vary", "Accept-Encoding")
+	return GzipLevel(gzip.DefaultCompression)(handler)
+}
 
-		gz := gzip.NewWriter(w)
-		defer gz.Close()
+/*
+GzipLevel returns a middleware like Gzip that compresses at the given level, from
+gzip.BestSpeed to gzip.BestCompression, or gzip.DefaultCompression. It panics if
+the level is outside that range, so a misconfiguration surfaces at startup.
 
-		handler.ServeHTTP(gzipResponseWriter{Writer: gz, ResponseWriter: w}, r)
-	})
+	r.Use(middleware.GzipLevel(gzip.BestSpeed, middleware.WithGzipMinSize(512)))
+*/
+func GzipLevel(level int, options ...GzipOption) func(http.Handler) http.Handler {
+	if level != gzip.DefaultCompression && (level < gzip.BestSpeed || level > gzip.BestCompression) {
+		panic(fmt.Sprintf("middleware: invalid gzip compression level %d", level))
+	}
+
+	cfg := &gzipConfig{MinSize: DefaultGzipMinSize}
+	for _, option := range options {
+		option(cfg)
+	}
+
+	return func(handler http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if !AcceptsEncoding(r.Header.Values("Accept-Encoding"), "gzip") {
+				handler.ServeHTTP(w, r)
+				return
+			}
+			addVary(w.Header(), "Accept-Encoding")
+
+			cw := &compressResponseWriter{ResponseWriter: w, coding: "gzip", minSize: cfg.MinSize, encoder: func(w io.Writer) io.WriteCloser {
+				return newPooledGzipWriter(w, level)
+			}}
+			// Deferred so the writer is flushed and returned to its pool even if the
+			// handler panics
+			defer cw.Close()
+
+			handler.ServeHTTP(cw, r)
+		})
+	}
 }
 
-// A gzipResponseWriter wraps an http.ResponseWriter and a gzip.Writer
-// to compress the response.
-type gzipResponseWriter struct {
-	io.Writer
+// A compressResponseWriter wraps an http.ResponseWriter to compress the response
+// with an encoder. The Content-Encoding header is only set, and the encoder only
+// created, once the response turns out to have a body. If minSize is positive, the
+// body is buffered and the response header held back until the body reaches
+// minSize bytes; smaller bodies are sent uncompressed when the writer is closed,
+// unless the handler flushes the response first.
+type compressResponseWriter struct {
 	http.ResponseWriter
+	coding      string
+	encoder     Encoder
+	writer      io.WriteCloser
+	wroteHeader bool
+
+	minSize   int
+	buffering bool
+	status    int
+	buf       []byte
+}
+
+func (w *compressResponseWriter) WriteHeader(code int) {
+	// Informational responses such as 103 Early Hints precede the final status
+	if code < http.StatusOK {
+		w.ResponseWriter.WriteHeader(code)
+		return
+	}
+	if w.wroteHeader {
+		return
+	}
+	w.wroteHeader = true
+
+	if code == http.StatusNoContent || code == http.StatusNotModified {
+		w.ResponseWriter.WriteHeader(code)
+		return
+	}
+	if w.minSize > 0 {
+		w.buffering, w.status = true, code
+		return
+	}
+	w.start(code, true)
+}
+
+// start writes the response header with the status code, compressing the body
+// that follows if compress is true.
+func (w *compressResponseWriter) start(code int, compress bool) {
+	if compress {
+		w.Header().Set("Content-Encoding", w.coding)
+		w.Header().Del("Content-Length")
+		w.writer = w.encoder(w.ResponseWriter)
+	}
+	w.ResponseWriter.WriteHeader(code)
 }
 
-func (w gzipResponseWriter) Write(b []byte) (int, error) {
-	return w.Writer.Write(b)
+func (w *compressResponseWriter) Write(b []byte) (int, error) {
+	if !w.wroteHeader {
+		w.WriteHeader(http.StatusOK)
+	}
+	if w.buffering {
+		w.buf = append(w.buf, b...)
+		if len(w.buf) < w.minSize {
+			return len(b), nil
+		}
+		if err := w.endBuffering(); err != nil {
+			return 0, err
+		}
+		return len(b), nil
+	}
+	if w.writer == nil {
+		return w.ResponseWriter.Write(b)
+	}
+	return w.writer.Write(b)
+}
+
+// endBuffering starts the compressed response and writes the buffered body to it.
+func (w *compressResponseWriter) endBuffering() error {
+	w.buffering = false
+	w.start(w.status, true)
+	buf := w.buf
+	w.buf = nil
+	if len(buf) == 0 {
+		return nil
+	}
+	_, err := w.writer.Write(buf)
+	return err
+}
+
+// Flush sends the response header and the body written so far, compressed, so
+// streamed responses such as server-sent events are not held back by buffering.
+func (w *compressResponseWriter) Flush() {
+	if !w.wroteHeader {
+		w.WriteHeader(http.StatusOK)
+	}
+	if w.buffering {
+		w.endBuffering() // nolint: errcheck
+	}
+	if f, ok := w.writer.(interface{ Flush() error }); ok {
+		f.Flush() // nolint: errcheck
+	}
+	if f, ok := w.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+// Unwrap returns the wrapped http.ResponseWriter, for http.ResponseController.
+func (w *compressResponseWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+// Close sends a buffered body below the size threshold uncompressed, or flushes and
+// closes the encoder, if the response had a body.
+func (w *compressResponseWriter) Close() error {
+	if w.buffering {
+		w.buffering = false
+		w.start(w.status, false)
+		if len(w.buf) == 0 {
+			return nil
+		}
+		_, err := w.ResponseWriter.Write(w.buf)
+		w.buf = nil
+		return err
+	}
+	if w.writer == nil {
+		return nil
+	}
+	return w.writer.Close()
 }
@@ -3,10 +3,13 @@ package middleware
 import (
 	"bytes"
 	"compress/gzip"
+	"io"
 	"io/ioutil"
 	"net/http"
 	"net/http/httptest"
+	"strconv"
 	"strings"
+	"sync"
 	"testing"
 )
 
@@ -23,10 +26,18 @@ func TestGzip(t *testing.T) {
 			name:                "gzip content encoding",
 			contentType:         "text/plain",
 			acceptEncoding:      "gzip",
-			expectedBody:        "This is some sample text",
+			expectedBody:        strings.Repeat("This is some sample text. ", 100),
 			expectedContentType: "text/plain",
 			expectedEncoding:    "gzip",
 		},
+		{
+			name:                "body below minimum size",
+			contentType:         "text/plain",
+			acceptEncoding:      "gzip",
+			expectedBody:        "This is some sample text",
+			expectedContentType: "text/plain",
+			expectedEncoding:    "",
+		},
 		{
 			name:                "no gzip content encoding",
 			contentType:         "text/plain",
@@ -98,3 +109,252 @@ func TestGzip(t *testing.T) {
 		})
 	}
 }
+
+func TestGzipLevel(t *testing.T) {
+	body := strings.Repeat("This is some sample text. ", 100)
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(body)) // nolint: errcheck
+	})
+
+	for _, level := range []int{gzip.DefaultCompression, gzip.BestSpeed, gzip.BestCompression} {
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.Header.Set("Accept-Encoding", "gzip")
+		rr := httptest.NewRecorder()
+
+		GzipLevel(level)(handler).ServeHTTP(rr, req)
+
+		if rr.Header().Get("Content-Encoding") != "gzip" {
+			t.Fatalf("level %d: expected Content-Encoding %q, got %q", level, "gzip", rr.Header().Get("Content-Encoding"))
+		}
+		gr, err := gzip.NewReader(rr.Body)
+		if err != nil {
+			t.Fatalf("level %d: %v", level, err)
+		}
+		decoded, err := ioutil.ReadAll(gr)
+		if err != nil {
+			t.Fatalf("level %d: %v", level, err)
+		}
+		if string(decoded) != body {
+			t.Errorf("level %d: unexpected decompressed body", level)
+		}
+	}
+
+	for _, level := range []int{gzip.NoCompression, gzip.HuffmanOnly, gzip.BestCompression + 1} {
+		func() {
+			defer func() {
+				if recover() == nil {
+					t.Errorf("expected GzipLevel(%d) to panic", level)
+				}
+			}()
+			GzipLevel(level)
+		}()
+	}
+}
+
+func TestGzipMinSize(t *testing.T) {
+	tests := []struct {
+		name             string
+		options          []GzipOption
+		writes           []string
+		expectedEncoding string
+	}{
+		{"below default", nil, []string{strings.Repeat("a", DefaultGzipMinSize-1)}, ""},
+		{"at default", nil, []string{strings.Repeat("a", DefaultGzipMinSize)}, "gzip"},
+		{"threshold reached across writes", nil, []string{strings.Repeat("a", 1000), strings.Repeat("b", 1000)}, "gzip"},
+		{"custom threshold", []GzipOption{WithGzipMinSize(10)}, []string{"hello, world"}, "gzip"},
+		{"threshold disabled", []GzipOption{WithGzipMinSize(0)}, []string{"hi"}, "gzip"},
+		{"empty body", nil, nil, ""},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			handler := GzipLevel(gzip.DefaultCompression, tc.options...)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Content-Type", "text/plain")
+				w.WriteHeader(http.StatusCreated)
+				for _, s := range tc.writes {
+					w.Write([]byte(s)) // nolint: errcheck
+				}
+			}))
+
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			req.Header.Set("Accept-Encoding", "gzip")
+			rr := httptest.NewRecorder()
+			handler.ServeHTTP(rr, req)
+
+			if rr.Code != http.StatusCreated {
+				t.Errorf("expected status code %d, got %d", http.StatusCreated, rr.Code)
+			}
+			if got := rr.Header().Get("Content-Encoding"); got != tc.expectedEncoding {
+				t.Fatalf("expected Content-Encoding %q, got %q", tc.expectedEncoding, got)
+			}
+
+			body := rr.Body.Bytes()
+			if tc.expectedEncoding == "gzip" {
+				gr, err := gzip.NewReader(rr.Body)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if body, err = ioutil.ReadAll(gr); err != nil {
+					t.Fatal(err)
+				}
+			}
+			if expected := strings.Join(tc.writes, ""); string(body) != expected {
+				t.Errorf("expected body of %d bytes, got %d", len(expected), len(body))
+			}
+		})
+	}
+}
+
+func TestGzip_AcceptEncoding(t *testing.T) {
+	body := strings.Repeat("This is some sample text. ", 100)
+	handler := Gzip(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(body)) // nolint: errcheck
+	}))
+
+	tests := []struct {
+		acceptEncoding   string
+		expectedEncoding string
+	}{
+		{"gzip", "gzip"},
+		{"gzip;q=0", ""},
+		{"gzip; q=0.0", ""},
+		{"identity, gzip;q=0.5", ""},
+		{"gzip;q=0.5", "gzip"},
+		{"*;q=0", ""},
+		{"*", "gzip"},
+		{"*;q=0, gzip", "gzip"},
+		{"deflate, GZIP;q=0.8", "gzip"},
+		{"x-gzip-like", ""},
+	}
+
+	for _, tc := range tests {
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.Header.Set("Accept-Encoding", tc.acceptEncoding)
+		rr := httptest.NewRecorder()
+		handler.ServeHTTP(rr, req)
+
+		if got := rr.Header().Get("Content-Encoding"); got != tc.expectedEncoding {
+			t.Errorf("Accept-Encoding %q: expected Content-Encoding %q, got %q", tc.acceptEncoding, tc.expectedEncoding, got)
+		}
+		if tc.expectedEncoding == "" && rr.Body.String() != body {
+			t.Errorf("Accept-Encoding %q: expected uncompressed body", tc.acceptEncoding)
+		}
+	}
+}
+
+func TestGzip_PooledWriters(t *testing.T) {
+	handler := Gzip(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body := strings.Repeat(r.URL.Query().Get("id")+" ", 1000)
+		for i := 0; i < len(body); i += 500 {
+			w.Write([]byte(body[i : i+500])) // nolint: errcheck
+		}
+	}))
+
+	var wg sync.WaitGroup
+	for i := 0; i < 50; i++ {
+		wg.Add(1)
+		go func(id string) {
+			defer wg.Done()
+
+			req := httptest.NewRequest(http.MethodGet, "/?id="+id, nil)
+			req.Header.Set("Accept-Encoding", "gzip")
+			rr := httptest.NewRecorder()
+			handler.ServeHTTP(rr, req)
+
+			gr, err := gzip.NewReader(rr.Body)
+			if err != nil {
+				t.Errorf("request %s: %v", id, err)
+				return
+			}
+			decoded, err := ioutil.ReadAll(gr)
+			if err != nil {
+				t.Errorf("request %s: %v", id, err)
+				return
+			}
+			if string(decoded) != strings.Repeat(id+" ", 1000) {
+				t.Errorf("request %s: unexpected body", id)
+			}
+		}(strconv.Itoa(i))
+	}
+	wg.Wait()
+}
+
+func TestGzip_PanicClosesWriter(t *testing.T) {
+	body := strings.Repeat("partial ", 500)
+	handler := Gzip(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(body)) // nolint: errcheck
+		panic("boom")
+	}))
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set("Accept-Encoding", "gzip")
+	rr := httptest.NewRecorder()
+	func() {
+		defer func() {
+			if recover() == nil {
+				t.Error("expected the panic to propagate")
+			}
+		}()
+		handler.ServeHTTP(rr, req)
+	}()
+
+	// The compressed stream is completed on the way out of the panic
+	gr, err := gzip.NewReader(rr.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	decoded, err := ioutil.ReadAll(gr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(decoded) != body {
+		t.Errorf("expected %d bytes, got %d", len(body), len(decoded))
+	}
+}
+
+func TestGzip_Flush(t *testing.T) {
+	handler := Gzip(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		w.Write([]byte("data: ping\n\n")) // nolint: errcheck
+		w.(http.Flusher).Flush()
+
+		// The event must reach the client before the handler returns
+		rr := w.(interface{ Unwrap() http.ResponseWriter }).Unwrap().(*httptest.ResponseRecorder)
+		if !rr.Flushed {
+			t.Error("expected the response to be flushed")
+		}
+		gr, err := gzip.NewReader(bytes.NewReader(rr.Body.Bytes()))
+		if err != nil {
+			t.Fatal(err)
+		}
+		event := make([]byte, len("data: ping\n\n"))
+		if _, err := io.ReadFull(gr, event); err != nil || string(event) != "data: ping\n\n" {
+			t.Errorf("expected the flushed event, got %q (%v)", event, err)
+		}
+	}))
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set("Accept-Encoding", "gzip")
+	rr := httptest.NewRecorder()
+	handler.ServeHTTP(rr, req)
+
+	if got := rr.Header().Get("Content-Encoding"); got != "gzip" {
+		t.Errorf("expected Content-Encoding %q, got %q", "gzip", got)
+	}
+}
+
+func TestGzip_EarlyHints(t *testing.T) {
+	handler := Gzip(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusEarlyHints)
+		w.WriteHeader(http.StatusCreated)
+	}))
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set("Accept-Encoding", "gzip")
+	rec := WrapResponseWriter(httptest.NewRecorder())
+	handler.ServeHTTP(rec, req)
+
+	if rec.Status() != http.StatusCreated {
+		t.Errorf("expected status code %d, got %d", http.StatusCreated, rec.Status())
+	}
+}
@@ -0,0 +1,54 @@
+package middleware
+
+import (
+	"net/http"
+	"net/textproto"
+	"strings"
+)
+
+// hopByHopHeaders are the headers defined as hop-by-hop by RFC 7230, section 6.1,
+// together with the legacy Proxy-Connection and the RFC 2616 "Trailers" spelling.
+var hopByHopHeaders = []string{
+	"Connection",
+	"Keep-Alive",
+	"Proxy-Authenticate",
+	"Proxy-Authorization",
+	"Proxy-Connection",
+	"Te",
+	"Trailer",
+	"Trailers",
+	"Transfer-Encoding",
+	"Upgrade",
+}
+
+/*
+StripHopByHop is a middleware that removes hop-by-hop headers from the incoming
+request before it reaches the next handler, as required of proxies by RFC 7230.
+
+Besides the standard hop-by-hop headers (Connection, Keep-Alive, Proxy-Authenticate,
+Proxy-Authorization, TE, Trailer, Transfer-Encoding and Upgrade), any header named
+in the Connection header is removed as well. All other headers are preserved.
+
+Usage:
+
+	r := muxer.NewRouter()
+	r.Use(middleware.StripHopByHop)
+*/
+func StripHopByHop(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// Headers listed in Connection must be removed before Connection itself
+		for _, value := range r.Header.Values("Connection") {
+			for _, name := range strings.Split(value, ",") {
+				if name = textproto.TrimString(name); name != "" {
+					r.Header.Del(name)
+				}
+			}
+		}
+
+		for _, name := range hopByHopHeaders {
+			r.Header.Del(name)
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
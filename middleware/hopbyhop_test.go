@@ -0,0 +1,47 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestStripHopByHop(t *testing.T) {
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set("Connection", "keep-alive, X-Custom-Hop")
+	req.Header.Set("Keep-Alive", "timeout=5")
+	req.Header.Set("Proxy-Authenticate", "Basic")
+	req.Header.Set("TE", "trailers")
+	req.Header.Set("Trailers", "X-Checksum")
+	req.Header.Set("Transfer-Encoding", "chunked")
+	req.Header.Set("Upgrade", "websocket")
+	req.Header.Set("X-Custom-Hop", "remove me")
+	req.Header.Set("Authorization", "Bearer token")
+	req.Header.Set("Accept", "application/json")
+
+	var received http.Header
+	handler := StripHopByHop(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		received = r.Header.Clone()
+	}))
+	handler.ServeHTTP(httptest.NewRecorder(), req)
+
+	removed := []string{
+		"Connection", "Keep-Alive", "Proxy-Authenticate", "Te", "Trailers",
+		"Transfer-Encoding", "Upgrade", "X-Custom-Hop",
+	}
+	for _, name := range removed {
+		if v := received.Get(name); v != "" {
+			t.Errorf("expected header %s to be removed, got %q", name, v)
+		}
+	}
+
+	preserved := map[string]string{
+		"Authorization": "Bearer token",
+		"Accept":        "application/json",
+	}
+	for name, expected := range preserved {
+		if got := received.Get(name); got != expected {
+			t.Errorf("expected header %s with value %q, got %q", name, expected, got)
+		}
+	}
+}
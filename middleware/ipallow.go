@@ -0,0 +1,83 @@
+package middleware
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
+)
+
+// realIPKey is the context key under which the client IP resolved by a proxy-aware
+// middleware is stored.
+const realIPKey contextKey = "real_ip"
+
+// WithRealIP returns a copy of ctx recording ip as the client IP of the request. A
+// middleware resolving the client behind trusted proxies sets it, and IPAllowList
+// then checks it instead of the address of the immediate peer.
+func WithRealIP(ctx context.Context, ip string) context.Context {
+	return context.WithValue(ctx, realIPKey, ip)
+}
+
+// RealIP returns the client IP recorded with WithRealIP, or else the host part of
+// the request's RemoteAddr.
+func RealIP(r *http.Request) string {
+	if ip, ok := r.Context().Value(realIPKey).(string); ok && ip != "" {
+		return ip
+	}
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
+
+/*
+IPAllowList is a middleware that only lets requests from the given IP addresses or
+CIDR ranges through and rejects all others with 403 Forbidden. The client IP is
+taken from RealIP, so a preceding middleware can record the client behind trusted
+proxies with WithRealIP. IPv4 and IPv6 are supported. IPAllowList panics if an
+entry is neither an IP address nor a CIDR range, so a misconfiguration surfaces at
+startup.
+
+Usage:
+
+	admin := router.Subrouter("/admin")
+	admin.Use(middleware.IPAllowList("10.0.0.0/8", "192.168.1.7", "2001:db8::/32"))
+*/
+func IPAllowList(cidrs ...string) func(http.Handler) http.Handler {
+	networks := make([]*net.IPNet, 0, len(cidrs))
+	for _, cidr := range cidrs {
+		if !strings.Contains(cidr, "/") {
+			ip := net.ParseIP(cidr)
+			if ip == nil {
+				panic(fmt.Sprintf("middleware: invalid IP address %q", cidr))
+			}
+			bits := 8 * net.IPv6len
+			if ip.To4() != nil {
+				ip, bits = ip.To4(), 8*net.IPv4len
+			}
+			networks = append(networks, &net.IPNet{IP: ip, Mask: net.CIDRMask(bits, bits)})
+			continue
+		}
+		_, network, err := net.ParseCIDR(cidr)
+		if err != nil {
+			panic(fmt.Sprintf("middleware: invalid CIDR range %q", cidr))
+		}
+		networks = append(networks, network)
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if ip := net.ParseIP(RealIP(r)); ip != nil {
+				for _, network := range networks {
+					if network.Contains(ip) {
+						next.ServeHTTP(w, r)
+						return
+					}
+				}
+			}
+			http.Error(w, "Forbidden", http.StatusForbidden)
+		})
+	}
+}
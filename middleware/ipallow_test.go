@@ -0,0 +1,59 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestIPAllowList(t *testing.T) {
+	handler := IPAllowList("10.0.0.0/8", "192.168.1.7", "2001:db8::/32")(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	tests := []struct {
+		name         string
+		remoteAddr   string
+		realIP       string
+		expectedCode int
+	}{
+		{"allowed IPv4 range", "10.1.2.3:51234", "", http.StatusOK},
+		{"allowed single IPv4", "192.168.1.7:51234", "", http.StatusOK},
+		{"disallowed IPv4", "192.168.1.8:51234", "", http.StatusForbidden},
+		{"allowed IPv6 range", "[2001:db8::1]:51234", "", http.StatusOK},
+		{"disallowed IPv6", "[2001:db9::1]:51234", "", http.StatusForbidden},
+		{"IPv4-mapped IPv6", "[::ffff:10.0.0.1]:51234", "", http.StatusOK},
+		{"real IP allowed behind proxy", "203.0.113.5:51234", "10.0.0.1", http.StatusOK},
+		{"real IP disallowed behind allowed proxy", "10.0.0.1:51234", "203.0.113.5", http.StatusForbidden},
+		{"unparseable address", "unix", "", http.StatusForbidden},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/admin", nil)
+			req.RemoteAddr = tc.remoteAddr
+			if tc.realIP != "" {
+				req = req.WithContext(WithRealIP(req.Context(), tc.realIP))
+			}
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, req)
+
+			if w.Code != tc.expectedCode {
+				t.Errorf("expected status code %d, got %d", tc.expectedCode, w.Code)
+			}
+		})
+	}
+}
+
+func TestIPAllowList_InvalidEntry(t *testing.T) {
+	for _, entry := range []string{"10.0.0.0/33", "not-an-ip"} {
+		func() {
+			defer func() {
+				if recover() == nil {
+					t.Errorf("expected IPAllowList(%q) to panic", entry)
+				}
+			}()
+			IPAllowList(entry)
+		}()
+	}
+}
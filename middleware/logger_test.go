@@ -0,0 +1,55 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"testing"
+)
+
+func TestLogger(t *testing.T) {
+	tests := []struct {
+		name     string
+		handler  http.HandlerFunc
+		expected string
+	}{
+		{
+			name: "fields added by handler",
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				AddLogField(r, "user_id", 42)
+				AddLogField(r, "tenant", "acme")
+				w.WriteHeader(http.StatusCreated)
+			},
+			expected: `^POST /orders 201 \S+ user_id=42 tenant=acme$`,
+		},
+		{
+			name: "values needing quotes",
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				AddLogField(r, "agent", "curl 8.0")
+				AddLogField(r, "empty", "")
+			},
+			expected: `^POST /orders 200 \S+ agent="curl 8.0" empty=""$`,
+		},
+		{
+			name:     "no fields",
+			handler:  func(w http.ResponseWriter, r *http.Request) {},
+			expected: `^POST /orders 200 \S+$`,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			logger := &mockLogger{}
+			Logger(logger)(tc.handler).ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodPost, "/orders", nil))
+
+			if line := logger.buf.String(); !regexp.MustCompile(tc.expected).MatchString(line) {
+				t.Errorf("expected log line matching %q, got %q", tc.expected, line)
+			}
+		})
+	}
+}
+
+func TestAddLogFieldWithoutLogger(t *testing.T) {
+	// Must not panic when the request is not served through Logger
+	AddLogField(httptest.NewRequest(http.MethodGet, "/", nil), "key", "value")
+}
@@ -0,0 +1,138 @@
+package middleware
+
+import (
+	"fmt"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// rateLimitSweepInterval is how often RateLimit drops the buckets of idle clients.
+const rateLimitSweepInterval = time.Minute
+
+// KeyByIP returns the client IP of the request as reported by RealIP, for use as the
+// key function of RateLimit.
+func KeyByIP(r *http.Request) string {
+	return RealIP(r)
+}
+
+// KeyByForwardedIP returns the client IP from the first X-Forwarded-For entry, falling
+// back to RealIP, for use as the key function of RateLimit. The header is set by the
+// client unless a proxy overwrites it, so only use it behind a trusted proxy.
+func KeyByForwardedIP(r *http.Request) string {
+	forwarded := r.Header.Get("X-Forwarded-For")
+	if i := strings.IndexByte(forwarded, ','); i >= 0 {
+		forwarded = forwarded[:i]
+	}
+	if ip := strings.TrimSpace(forwarded); ip != "" {
+		return ip
+	}
+	return RealIP(r)
+}
+
+/*
+RateLimit is a middleware that limits each client to rate requests per second on
+average, with bursts of up to burst requests, using a token bucket per client.
+Clients are told apart by keyFunc, KeyByIP if nil; use KeyByForwardedIP behind a
+trusted proxy, or a function returning an API key or user ID. Requests over the
+limit are rejected with 429 Too Many Requests and a Retry-After header giving the
+seconds until a token is available.
+
+The buckets of clients that have been idle long enough to refill completely are
+dropped periodically, so memory use follows the number of active clients.
+RateLimit panics if rate is not positive or burst is less than 1.
+
+Usage:
+
+	r := muxer.NewRouter()
+	r.Use(middleware.RateLimit(10, 20, middleware.KeyByIP))
+
+KeyByForwardedIP is only safe behind a trusted proxy that overwrites
+X-Forwarded-For; otherwise clients can pick their own key and evade the limit.
+*/
+func RateLimit(rate float64, burst int, keyFunc func(r *http.Request) string) func(http.Handler) http.Handler {
+	if rate <= 0 || burst < 1 {
+		panic(fmt.Sprintf("middleware: invalid rate limit of %v requests per second with burst %d", rate, burst))
+	}
+	if keyFunc == nil {
+		keyFunc = KeyByIP
+	}
+	limiter := &rateLimiter{rate: rate, burst: float64(burst), lastSweep: time.Now()}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			ok, wait := limiter.allow(keyFunc(r), time.Now())
+			if !ok {
+				w.Header().Set("Retry-After", strconv.FormatInt(int64(math.Ceil(wait.Seconds())), 10))
+				http.Error(w, "Too Many Requests", http.StatusTooManyRequests)
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// rateLimiter holds a token bucket per client key.
+type rateLimiter struct {
+	rate    float64
+	burst   float64
+	buckets sync.Map // key -> *tokenBucket
+
+	mu        sync.Mutex
+	lastSweep time.Time
+}
+
+// tokenBucket holds the tokens of a client as of last.
+type tokenBucket struct {
+	mu     sync.Mutex
+	tokens float64
+	last   time.Time
+}
+
+// allow takes a token from the bucket of key at now. If none is available, it
+// reports how long it takes until one is.
+func (l *rateLimiter) allow(key string, now time.Time) (bool, time.Duration) {
+	l.sweep(now)
+
+	v, _ := l.buckets.LoadOrStore(key, &tokenBucket{tokens: l.burst, last: now})
+	bucket := v.(*tokenBucket)
+
+	bucket.mu.Lock()
+	defer bucket.mu.Unlock()
+
+	if elapsed := now.Sub(bucket.last).Seconds(); elapsed > 0 {
+		bucket.tokens = math.Min(l.burst, bucket.tokens+elapsed*l.rate)
+		bucket.last = now
+	}
+	if bucket.tokens >= 1 {
+		bucket.tokens--
+		return true, 0
+	}
+	return false, time.Duration((1 - bucket.tokens) / l.rate * float64(time.Second))
+}
+
+// sweep drops the buckets that have refilled completely, and so behave like new
+// ones, at most once per rateLimitSweepInterval.
+func (l *rateLimiter) sweep(now time.Time) {
+	l.mu.Lock()
+	if now.Sub(l.lastSweep) < rateLimitSweepInterval {
+		l.mu.Unlock()
+		return
+	}
+	l.lastSweep = now
+	l.mu.Unlock()
+
+	refill := time.Duration(l.burst / l.rate * float64(time.Second))
+	l.buckets.Range(func(key, v interface{}) bool {
+		bucket := v.(*tokenBucket)
+		bucket.mu.Lock()
+		if now.Sub(bucket.last) >= refill {
+			l.buckets.Delete(key)
+		}
+		bucket.mu.Unlock()
+		return true
+	})
+}
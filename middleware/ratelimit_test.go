@@ -0,0 +1,111 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestRateLimit(t *testing.T) {
+	handler := RateLimit(0.5, 2, nil)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	tests := []struct {
+		remoteAddr         string
+		expectedCode       int
+		expectedRetryAfter string
+	}{
+		{"192.0.2.1:1234", http.StatusOK, ""},
+		{"192.0.2.1:1235", http.StatusOK, ""},
+		{"192.0.2.1:1236", http.StatusTooManyRequests, "2"},
+		{"192.0.2.2:1234", http.StatusOK, ""},
+	}
+
+	for _, tc := range tests {
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.RemoteAddr = tc.remoteAddr
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, req)
+
+		if w.Code != tc.expectedCode {
+			t.Errorf("%s: expected status code %d, got %d", tc.remoteAddr, tc.expectedCode, w.Code)
+		}
+		if got := w.Header().Get("Retry-After"); got != tc.expectedRetryAfter {
+			t.Errorf("%s: expected Retry-After %q, got %q", tc.remoteAddr, tc.expectedRetryAfter, got)
+		}
+	}
+}
+
+func TestRateLimit_KeyFunc(t *testing.T) {
+	handler := RateLimit(0.5, 1, KeyByForwardedIP)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+
+	tests := []struct {
+		forwardedFor string
+		expectedCode int
+	}{
+		{"203.0.113.1, 10.0.0.1", http.StatusOK},
+		{"203.0.113.2, 10.0.0.1", http.StatusOK},
+		{"203.0.113.1", http.StatusTooManyRequests},
+		{"", http.StatusOK},
+		{"", http.StatusTooManyRequests},
+	}
+
+	for _, tc := range tests {
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.RemoteAddr = "10.0.0.1:1234"
+		if tc.forwardedFor != "" {
+			req.Header.Set("X-Forwarded-For", tc.forwardedFor)
+		}
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, req)
+
+		if w.Code != tc.expectedCode {
+			t.Errorf("X-Forwarded-For %q: expected status code %d, got %d", tc.forwardedFor, tc.expectedCode, w.Code)
+		}
+	}
+}
+
+func TestRateLimiter_RefillAndSweep(t *testing.T) {
+	start := time.Now()
+	limiter := &rateLimiter{rate: 1, burst: 2, lastSweep: start}
+
+	for i, expected := range []bool{true, true, false} {
+		if ok, _ := limiter.allow("client", start); ok != expected {
+			t.Errorf("request %d: expected allowed=%v, got %v", i, expected, ok)
+		}
+	}
+	if ok, wait := limiter.allow("client", start.Add(500*time.Millisecond)); ok || wait != 500*time.Millisecond {
+		t.Errorf("expected a wait of 500ms, got allowed=%v wait=%v", ok, wait)
+	}
+	if ok, _ := limiter.allow("client", start.Add(time.Second)); !ok {
+		t.Error("expected a token to have refilled after a second")
+	}
+
+	// Idle buckets are dropped on the next sweep
+	limiter.allow("other", start.Add(rateLimitSweepInterval-time.Second))
+	limiter.allow("other", start.Add(rateLimitSweepInterval))
+	if _, ok := limiter.buckets.Load("client"); ok {
+		t.Error("expected the idle bucket to be swept")
+	}
+	if _, ok := limiter.buckets.Load("other"); !ok {
+		t.Error("expected the active bucket to be kept")
+	}
+}
+
+func TestRateLimit_InvalidParameters(t *testing.T) {
+	for _, tc := range []struct {
+		rate  float64
+		burst int
+	}{{0, 1}, {-1, 1}, {1, 0}} {
+		func() {
+			defer func() {
+				if recover() == nil {
+					t.Errorf("expected RateLimit(%v, %d) to panic", tc.rate, tc.burst)
+				}
+			}()
+			RateLimit(tc.rate, tc.burst, nil)
+		}()
+	}
+}
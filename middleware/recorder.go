@@ -0,0 +1,103 @@
+package middleware
+
+import (
+	"bufio"
+	"errors"
+	"net"
+	"net/http"
+)
+
+/*
+ResponseRecorder wraps an http.ResponseWriter to record the status code and the
+number of body bytes written through it, for middleware such as loggers and
+metrics collectors. Unlike httptest.ResponseRecorder it passes everything through
+to the wrapped writer. Flush, Hijack and Push are forwarded when the wrapped
+writer supports them.
+
+Usage:
+
+	func timing(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			rec := middleware.WrapResponseWriter(w)
+			next.ServeHTTP(rec, r)
+			log.Printf("%s %d %d bytes", r.URL.Path, rec.Status(), rec.BytesWritten())
+		})
+	}
+*/
+type ResponseRecorder struct {
+	http.ResponseWriter
+	status  int
+	written int
+}
+
+// WrapResponseWriter returns a ResponseRecorder wrapping w.
+func WrapResponseWriter(w http.ResponseWriter) *ResponseRecorder {
+	return &ResponseRecorder{ResponseWriter: w}
+}
+
+// Status returns the status code of the response, 200 if the handler wrote a body
+// or nothing at all without calling WriteHeader.
+func (w *ResponseRecorder) Status() int {
+	if w.status == 0 {
+		return http.StatusOK
+	}
+	return w.status
+}
+
+// WroteHeader reports whether the final status has been sent, by WriteHeader or by
+// writing the body.
+func (w *ResponseRecorder) WroteHeader() bool {
+	return w.status != 0
+}
+
+// BytesWritten returns the number of body bytes written to the response.
+func (w *ResponseRecorder) BytesWritten() int {
+	return w.written
+}
+
+// Unwrap returns the wrapped http.ResponseWriter, for http.ResponseController.
+func (w *ResponseRecorder) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+func (w *ResponseRecorder) WriteHeader(code int) {
+	// Informational responses may precede the final status
+	if w.status == 0 && code >= http.StatusOK {
+		w.status = code
+	}
+	w.ResponseWriter.WriteHeader(code)
+}
+
+func (w *ResponseRecorder) Write(b []byte) (int, error) {
+	if w.status == 0 {
+		w.status = http.StatusOK
+	}
+	n, err := w.ResponseWriter.Write(b)
+	w.written += n
+	return n, err
+}
+
+// Flush passes through to the underlying writer so streaming responses keep working.
+func (w *ResponseRecorder) Flush() {
+	if f, ok := w.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+// Hijack passes through to the underlying writer, failing if it does not support
+// hijacking the connection.
+func (w *ResponseRecorder) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	if h, ok := w.ResponseWriter.(http.Hijacker); ok {
+		return h.Hijack()
+	}
+	return nil, nil, errors.New("middleware: underlying ResponseWriter does not implement http.Hijacker")
+}
+
+// Push passes through to the underlying writer, returning http.ErrNotSupported if
+// it does not support HTTP/2 server push.
+func (w *ResponseRecorder) Push(target string, opts *http.PushOptions) error {
+	if p, ok := w.ResponseWriter.(http.Pusher); ok {
+		return p.Push(target, opts)
+	}
+	return http.ErrNotSupported
+}
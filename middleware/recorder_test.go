@@ -0,0 +1,120 @@
+package middleware
+
+import (
+	"bufio"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestResponseRecorder(t *testing.T) {
+	tests := []struct {
+		name          string
+		handler       http.HandlerFunc
+		expectedCode  int
+		expectedBytes int
+	}{
+		{
+			name: "explicit status and body",
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(http.StatusCreated)
+				w.Write([]byte("hello, ")) // nolint: errcheck
+				w.Write([]byte("world"))   // nolint: errcheck
+			},
+			expectedCode:  http.StatusCreated,
+			expectedBytes: 12,
+		},
+		{
+			name: "implicit 200 on write",
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				w.Write([]byte("ok")) // nolint: errcheck
+			},
+			expectedCode:  http.StatusOK,
+			expectedBytes: 2,
+		},
+		{
+			name:          "implicit 200 without write",
+			handler:       func(w http.ResponseWriter, r *http.Request) {},
+			expectedCode:  http.StatusOK,
+			expectedBytes: 0,
+		},
+		{
+			name: "first status wins",
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(http.StatusNotFound)
+				w.WriteHeader(http.StatusInternalServerError)
+			},
+			expectedCode:  http.StatusNotFound,
+			expectedBytes: 0,
+		},
+		{
+			name: "informational status is skipped",
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(http.StatusEarlyHints)
+				w.WriteHeader(http.StatusAccepted)
+			},
+			expectedCode:  http.StatusAccepted,
+			expectedBytes: 0,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			rec := WrapResponseWriter(httptest.NewRecorder())
+			tc.handler(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+
+			if rec.Status() != tc.expectedCode {
+				t.Errorf("expected status code %d, got %d", tc.expectedCode, rec.Status())
+			}
+			if rec.BytesWritten() != tc.expectedBytes {
+				t.Errorf("expected %d bytes written, got %d", tc.expectedBytes, rec.BytesWritten())
+			}
+		})
+	}
+}
+
+// hijackPushWriter is a ResponseWriter supporting hijacking and server push.
+type hijackPushWriter struct {
+	*httptest.ResponseRecorder
+	hijacked bool
+	pushed   string
+}
+
+func (w *hijackPushWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	w.hijacked = true
+	return nil, nil, nil
+}
+
+func (w *hijackPushWriter) Push(target string, opts *http.PushOptions) error {
+	w.pushed = target
+	return nil
+}
+
+func TestResponseRecorder_Passthrough(t *testing.T) {
+	underlying := &hijackPushWriter{ResponseRecorder: httptest.NewRecorder()}
+	rec := WrapResponseWriter(underlying)
+
+	var w http.ResponseWriter = rec
+	w.(http.Flusher).Flush()
+	if !underlying.Flushed {
+		t.Error("expected Flush to reach the underlying writer")
+	}
+	if _, _, err := w.(http.Hijacker).Hijack(); err != nil || !underlying.hijacked {
+		t.Errorf("expected Hijack to reach the underlying writer, got err %v", err)
+	}
+	if err := w.(http.Pusher).Push("/app.css", nil); err != nil || underlying.pushed != "/app.css" {
+		t.Errorf("expected Push to reach the underlying writer, got err %v", err)
+	}
+	if rec.Unwrap() != underlying {
+		t.Error("expected Unwrap to return the underlying writer")
+	}
+
+	plain := WrapResponseWriter(httptest.NewRecorder())
+	if _, _, err := plain.Hijack(); err == nil {
+		t.Error("expected Hijack to fail on a writer without hijacking support")
+	}
+	if err := plain.Push("/app.css", nil); err != http.ErrNotSupported {
+		t.Errorf("expected http.ErrNotSupported, got %v", err)
+	}
+}
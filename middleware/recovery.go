@@ -1,6 +1,7 @@
 package middleware
 
 import (
+	"encoding/json"
 	"log"
 	"net/http"
 	"runtime/debug"
@@ -11,12 +12,96 @@ type RecoveryLogger interface {
 	Println(v ...interface{})
 }
 
+// StructuredLogger is an optional interface a RecoveryLogger may implement to
+// receive leveled, key/value log records instead of Println calls. When the
+// logger given to RecoveryHandler implements it, a recovered panic is logged
+// with Error and the "error", "method", "path" and (optionally) "stack" attributes.
+type StructuredLogger interface {
+	Error(msg string, args ...interface{})
+}
+
+// PanicRenderer writes the response for a request whose handler panicked with err.
+type PanicRenderer func(w http.ResponseWriter, r *http.Request, err interface{})
+
+// PanicClassifier maps the value a handler panicked with to the status code and
+// message of the response. ok is false for values it does not recognize, which are
+// answered with 500 Internal Server Error.
+type PanicClassifier func(err interface{}) (status int, message string, ok bool)
+
+// RecoveryOption is a function that modifies the configuration of RecoveryHandler.
+type RecoveryOption func(*recoveryHandler)
+
+// WithPanicClassifier sets the classifier deciding which recovered panics are
+// answered with a status other than 500, replacing StatusCodeClassifier.
+func WithPanicClassifier(classifier PanicClassifier) RecoveryOption {
+	return func(rh *recoveryHandler) {
+		rh.classifier = classifier
+	}
+}
+
+/*
+StatusCodeClassifier is the default PanicClassifier. It recognizes panic values
+with a StatusCode() int method returning a 4xx or 5xx status, such as a deliberate
+panic(muxer.HTTPError{Status: http.StatusTooManyRequests, Message: "quota exceeded"}),
+and answers them with that status. The message is the one returned by the value's
+StatusMessage() string method if it has one and it is not empty, and the status
+text otherwise.
+*/
+func StatusCodeClassifier(err interface{}) (status int, message string, ok bool) {
+	coder, ok := err.(interface{ StatusCode() int })
+	if !ok {
+		return 0, "", false
+	}
+	status = coder.StatusCode()
+	if status < 400 || status > 599 {
+		return 0, "", false
+	}
+	if m, ok := err.(interface{ StatusMessage() string }); ok && m.StatusMessage() != "" {
+		return status, m.StatusMessage(), true
+	}
+	return status, http.StatusText(status), true
+}
+
+// WithPanicRenderer sets the renderer writing the 500 response for a recovered panic,
+// instead of an empty 500. Passing the same option to RecoveryHandler and to the
+// router's WithRecovery renders panics uniformly wherever they are recovered.
+func WithPanicRenderer(renderer PanicRenderer) RecoveryOption {
+	return func(rh *recoveryHandler) {
+		rh.renderer = renderer
+	}
+}
+
+/*
+JSONPanicRenderer is a PanicRenderer writing a 500 response with a JSON body that
+identifies the failed request without exposing the panic value:
+
+	{"error":"Internal Server Error","method":"GET","path":"/users/1","request_id":"..."}
+
+The request_id is included when the RequestID middleware ran before the panic.
+*/
+func JSONPanicRenderer(w http.ResponseWriter, r *http.Request, err interface{}) {
+	body := map[string]string{
+		"error":  http.StatusText(http.StatusInternalServerError),
+		"method": r.Method,
+		"path":   r.URL.Path,
+	}
+	if id := GetRequestID(r.Context()); id != "" {
+		body["request_id"] = id
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusInternalServerError)
+	json.NewEncoder(w).Encode(body) // nolint: errcheck
+}
+
 // recoveryHandler is an HTTP middleware that recovers from a panic, logs the panic,
 // writes http.StatusInternalServerError, and continues to the next handler.
 type recoveryHandler struct {
 	handler    http.Handler
 	logger     RecoveryLogger
 	printStack bool
+	renderer   PanicRenderer
+	classifier PanicClassifier
 }
 
 /*
@@ -42,17 +127,35 @@ http.ListenAndServe(":1123", r)
 The RecoveryHandler logs errors and, if printStack is true, also logs a
 stack trace. If printStack is false, no stack trace is logged. If no logger is
 provided, it uses the default Go logger.
+
+The response is an empty 500 unless a renderer is set with WithPanicRenderer.
+Panics recognized by the classifier, by default StatusCodeClassifier, are instead
+answered with the status and message it returns; see WithPanicClassifier.
 */
-func RecoveryHandler(logger RecoveryLogger, printStack bool) func(http.Handler) http.Handler {
+func RecoveryHandler(logger RecoveryLogger, printStack bool, options ...RecoveryOption) func(http.Handler) http.Handler {
 	return func(next http.Handler) http.Handler {
-		return &recoveryHandler{handler: next, logger: logger, printStack: printStack}
+		rh := &recoveryHandler{handler: next, logger: logger, printStack: printStack, classifier: StatusCodeClassifier}
+		for _, option := range options {
+			option(rh)
+		}
+		return rh
 	}
 }
 
 func (rh *recoveryHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	defer func() {
 		if err := recover(); err != nil {
-			w.WriteHeader(http.StatusInternalServerError)
+			if status, message, ok := rh.classify(err); ok {
+				http.Error(w, message, status)
+			} else if rh.renderer != nil {
+				rh.renderer(w, r, err)
+			} else {
+				w.WriteHeader(http.StatusInternalServerError)
+			}
+			if sl, ok := rh.logger.(StructuredLogger); ok {
+				rh.logStructured(sl, r, err)
+				return
+			}
 			rh.log(err)
 		}
 	}()
@@ -60,6 +163,14 @@ func (rh *recoveryHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	rh.handler.ServeHTTP(w, r)
 }
 
+// classify applies the classifier, if any, to a recovered panic value.
+func (rh *recoveryHandler) classify(err interface{}) (status int, message string, ok bool) {
+	if rh.classifier == nil {
+		return 0, "", false
+	}
+	return rh.classifier(err)
+}
+
 func (rh *recoveryHandler) log(v ...interface{}) {
 	if rh.logger != nil {
 		rh.logger.Println(v...)
@@ -78,3 +189,11 @@ func (rh *recoveryHandler) log(v ...interface{}) {
 		}
 	}
 }
+
+func (rh *recoveryHandler) logStructured(logger StructuredLogger, r *http.Request, err interface{}) {
+	args := []interface{}{"error", err, "method", r.Method, "path", r.URL.Path}
+	if rh.printStack {
+		args = append(args, "stack", string(debug.Stack()))
+	}
+	logger.Error("panic recovered", args...)
+}
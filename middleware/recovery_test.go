@@ -2,6 +2,7 @@ package middleware
 
 import (
 	"bytes"
+	"encoding/json"
 	"net/http"
 	"net/http/httptest"
 	"testing"
@@ -92,3 +93,86 @@ func TestRecoveryHandler(t *testing.T) {
 		})
 	}
 }
+
+func TestRecoveryHandler_PanicRenderer(t *testing.T) {
+	panicking := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		panic("unexpected error")
+	})
+	handler := RequestID()(RecoveryHandler(&mockLogger{}, false, WithPanicRenderer(JSONPanicRenderer))(panicking))
+
+	req := httptest.NewRequest(http.MethodPost, "/orders/7", nil)
+	req.Header.Set(RequestIDHeader, "req-123")
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusInternalServerError {
+		t.Errorf("expected status code %d, got %d", http.StatusInternalServerError, rec.Code)
+	}
+	if got := rec.Header().Get("Content-Type"); got != "application/json" {
+		t.Errorf("expected JSON content type, got %q", got)
+	}
+
+	var body map[string]string
+	if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil {
+		t.Fatalf("failed to decode body %q: %v", rec.Body.String(), err)
+	}
+	expected := map[string]string{
+		"error":      "Internal Server Error",
+		"method":     http.MethodPost,
+		"path":       "/orders/7",
+		"request_id": "req-123",
+	}
+	for key, value := range expected {
+		if body[key] != value {
+			t.Errorf("expected %s %q, got %q", key, value, body[key])
+		}
+	}
+}
+
+type forbiddenError struct{}
+
+func (forbiddenError) StatusCode() int { return http.StatusForbidden }
+
+type quotaError struct{}
+
+func (quotaError) StatusCode() int       { return http.StatusTooManyRequests }
+func (quotaError) StatusMessage() string { return "quota exceeded" }
+
+type discardLogger struct{}
+
+func (discardLogger) Println(v ...interface{}) {}
+
+func TestRecoveryHandler_PanicClassifier(t *testing.T) {
+	tests := []struct {
+		name         string
+		value        interface{}
+		options      []RecoveryOption
+		expectedCode int
+		expectedBody string
+	}{
+		{"value with status code", forbiddenError{}, nil, http.StatusForbidden, "Forbidden\n"},
+		{"value with status message", quotaError{}, nil, http.StatusTooManyRequests, "quota exceeded\n"},
+		{"other value", "boom", nil, http.StatusInternalServerError, ""},
+		{"custom classifier", "teapot", []RecoveryOption{WithPanicClassifier(func(err interface{}) (int, string, bool) {
+			return http.StatusTeapot, "short and stout", err == "teapot"
+		})}, http.StatusTeapot, "short and stout\n"},
+		{"classification disabled", forbiddenError{}, []RecoveryOption{WithPanicClassifier(nil)}, http.StatusInternalServerError, ""},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			panicking := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				panic(tc.value)
+			})
+			rec := httptest.NewRecorder()
+			RecoveryHandler(discardLogger{}, false, tc.options...)(panicking).ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+
+			if rec.Code != tc.expectedCode {
+				t.Errorf("expected status code %d, got %d", tc.expectedCode, rec.Code)
+			}
+			if rec.Body.String() != tc.expectedBody {
+				t.Errorf("expected body %q, got %q", tc.expectedBody, rec.Body.String())
+			}
+		})
+	}
+}
@@ -0,0 +1,104 @@
+package middleware
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"net/http"
+)
+
+// RequestIDHeader is the default header used to read and write request IDs.
+const RequestIDHeader = "X-Request-ID"
+
+// requestIDKey is the context key under which the request ID is stored.
+const requestIDKey contextKey = "request_id"
+
+/*
+The requestIDConfig struct contains the inbound headers honored for client-supplied
+request IDs, in order of precedence, and the response header the ID is written to.
+*/
+type requestIDConfig struct {
+	InboundHeaders []string
+	ResponseHeader string
+}
+
+// RequestIDOption is a function that modifies the request ID configuration.
+type RequestIDOption func(*requestIDConfig)
+
+// WithRequestIDHeaders sets the inbound headers that may carry a client-supplied
+// request or correlation ID. The first header present on the request wins.
+func WithRequestIDHeaders(names ...string) RequestIDOption {
+	return func(cfg *requestIDConfig) {
+		cfg.InboundHeaders = names
+	}
+}
+
+// WithRequestIDResponseHeader sets the response header the request ID is written to.
+func WithRequestIDResponseHeader(name string) RequestIDOption {
+	return func(cfg *requestIDConfig) {
+		cfg.ResponseHeader = name
+	}
+}
+
+/*
+RequestID is a middleware that assigns an ID to every request. If one of the
+configured inbound headers carries a client-supplied ID, the first one present is
+used; otherwise a random ID is generated. The ID is stored in the request context,
+where it can be read with GetRequestID, and written to the response header.
+
+By default the X-Request-ID header is used in both directions.
+
+Usage:
+
+	r := muxer.NewRouter()
+	r.Use(middleware.RequestID(
+		middleware.WithRequestIDHeaders("X-Request-ID", "X-Correlation-ID", "traceparent"),
+		middleware.WithRequestIDResponseHeader("X-Request-ID"),
+	))
+*/
+func RequestID(options ...RequestIDOption) func(http.Handler) http.Handler {
+	cfg := &requestIDConfig{
+		InboundHeaders: []string{RequestIDHeader},
+		ResponseHeader: RequestIDHeader,
+	}
+
+	for _, option := range options {
+		option(cfg)
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			var id string
+			for _, name := range cfg.InboundHeaders {
+				if id = r.Header.Get(name); id != "" {
+					break
+				}
+			}
+			if id == "" {
+				id = newRequestID()
+			}
+
+			if cfg.ResponseHeader != "" {
+				w.Header().Set(cfg.ResponseHeader, id)
+			}
+
+			next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), requestIDKey, id)))
+		})
+	}
+}
+
+// GetRequestID returns the request ID stored in ctx by the RequestID middleware,
+// or an empty string if there is none.
+func GetRequestID(ctx context.Context) string {
+	id, _ := ctx.Value(requestIDKey).(string)
+	return id
+}
+
+// newRequestID generates a random 128-bit request ID encoded as hex.
+func newRequestID() string {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return ""
+	}
+	return hex.EncodeToString(b)
+}
@@ -0,0 +1,83 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestRequestID(t *testing.T) {
+	tests := []struct {
+		name           string
+		options        []RequestIDOption
+		requestHeaders map[string]string
+		responseHeader string
+		expectedID     string
+	}{
+		{
+			name:           "generates an ID when none is supplied",
+			responseHeader: RequestIDHeader,
+		},
+		{
+			name:           "honors default inbound header",
+			requestHeaders: map[string]string{"X-Request-ID": "abc-123"},
+			responseHeader: RequestIDHeader,
+			expectedID:     "abc-123",
+		},
+		{
+			name: "honors configured correlation header",
+			options: []RequestIDOption{
+				WithRequestIDHeaders("X-Request-ID", "X-Correlation-ID"),
+			},
+			requestHeaders: map[string]string{"X-Correlation-ID": "corr-456"},
+			responseHeader: RequestIDHeader,
+			expectedID:     "corr-456",
+		},
+		{
+			name: "first present header wins",
+			options: []RequestIDOption{
+				WithRequestIDHeaders("X-Correlation-ID", "X-Request-ID"),
+			},
+			requestHeaders: map[string]string{"X-Request-ID": "req-1", "X-Correlation-ID": "corr-1"},
+			responseHeader: RequestIDHeader,
+			expectedID:     "corr-1",
+		},
+		{
+			name: "configurable response header",
+			options: []RequestIDOption{
+				WithRequestIDHeaders("X-Correlation-ID"),
+				WithRequestIDResponseHeader("X-Correlation-ID"),
+			},
+			requestHeaders: map[string]string{"X-Correlation-ID": "corr-789"},
+			responseHeader: "X-Correlation-ID",
+			expectedID:     "corr-789",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			for k, v := range tc.requestHeaders {
+				req.Header.Set(k, v)
+			}
+			rec := httptest.NewRecorder()
+
+			var contextID string
+			handler := RequestID(tc.options...)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				contextID = GetRequestID(r.Context())
+			}))
+			handler.ServeHTTP(rec, req)
+
+			responseID := rec.Header().Get(tc.responseHeader)
+			if contextID == "" {
+				t.Fatal("expected a request ID in the context")
+			}
+			if responseID != contextID {
+				t.Errorf("expected response header %s to be %q, got %q", tc.responseHeader, contextID, responseID)
+			}
+			if tc.expectedID != "" && contextID != tc.expectedID {
+				t.Errorf("expected request ID %q, got %q", tc.expectedID, contextID)
+			}
+		})
+	}
+}
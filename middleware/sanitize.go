@@ -0,0 +1,48 @@
+package middleware
+
+import (
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+/*
+SanitizePath returns a middleware that rejects requests whose decoded path contains
+null bytes or other control characters with 400 Bad Request. Such characters are
+never legitimate in a path and can enable log injection or path tricks.
+
+If checkQuery is true, the decoded query string is inspected as well.
+
+Usage:
+
+	r := muxer.NewRouter()
+	r.Use(middleware.SanitizePath(true))
+*/
+func SanitizePath(checkQuery bool) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if containsControlChars(r.URL.Path) {
+				http.Error(w, "Bad Request", http.StatusBadRequest)
+				return
+			}
+
+			if checkQuery && r.URL.RawQuery != "" {
+				query, err := url.QueryUnescape(r.URL.RawQuery)
+				if err != nil || containsControlChars(query) {
+					http.Error(w, "Bad Request", http.StatusBadRequest)
+					return
+				}
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// containsControlChars reports whether s contains an ASCII control character,
+// including the null byte and DEL.
+func containsControlChars(s string) bool {
+	return strings.IndexFunc(s, func(r rune) bool {
+		return r < 0x20 || r == 0x7f
+	}) >= 0
+}
@@ -0,0 +1,39 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestSanitizePath(t *testing.T) {
+	tests := []struct {
+		name         string
+		target       string
+		checkQuery   bool
+		expectedCode int
+	}{
+		{"normal path", "/users/123", false, http.StatusOK},
+		{"null byte in path", "/users/123%00.txt", false, http.StatusBadRequest},
+		{"newline in path", "/users/%0d%0aSet-Cookie:x", false, http.StatusBadRequest},
+		{"control char in query ignored", "/users?name=a%00b", false, http.StatusOK},
+		{"control char in query rejected", "/users?name=a%00b", true, http.StatusBadRequest},
+		{"normal query", "/users?name=gopher", true, http.StatusOK},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, tc.target, nil)
+			rec := httptest.NewRecorder()
+
+			handler := SanitizePath(tc.checkQuery)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(http.StatusOK)
+			}))
+			handler.ServeHTTP(rec, req)
+
+			if rec.Code != tc.expectedCode {
+				t.Errorf("expected status code %d, got %d", tc.expectedCode, rec.Code)
+			}
+		})
+	}
+}
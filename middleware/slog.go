@@ -0,0 +1,43 @@
+//go:build go1.21
+
+package middleware
+
+import (
+	"fmt"
+	"log/slog"
+	"strings"
+)
+
+/*
+SlogLogger adapts an *slog.Logger to the RecoveryLogger and StructuredLogger
+interfaces, so panics recovered by RecoveryHandler are emitted as structured
+records rather than plain lines.
+
+Usage:
+
+	logger := slog.New(slog.NewJSONHandler(os.Stderr, nil))
+	r.Use(middleware.RecoveryHandler(middleware.NewSlogLogger(logger), true))
+*/
+type SlogLogger struct {
+	Logger *slog.Logger
+}
+
+// NewSlogLogger returns a SlogLogger wrapping the given logger. If logger is nil,
+// slog.Default() is used.
+func NewSlogLogger(logger *slog.Logger) *SlogLogger {
+	if logger == nil {
+		logger = slog.Default()
+	}
+	return &SlogLogger{Logger: logger}
+}
+
+// Println logs the operands at info level, keeping compatibility with the
+// Println-based RecoveryLogger interface.
+func (l *SlogLogger) Println(v ...interface{}) {
+	l.Logger.Info(strings.TrimSuffix(fmt.Sprintln(v...), "\n"))
+}
+
+// Error logs msg at error level with the given key/value attributes.
+func (l *SlogLogger) Error(msg string, args ...interface{}) {
+	l.Logger.Error(msg, args...)
+}
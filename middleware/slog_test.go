@@ -0,0 +1,87 @@
+//go:build go1.21
+
+package middleware
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// captureHandler is a slog.Handler that records every log record it receives.
+type captureHandler struct {
+	records []slog.Record
+}
+
+func (h *captureHandler) Enabled(context.Context, slog.Level) bool { return true }
+
+func (h *captureHandler) Handle(_ context.Context, r slog.Record) error {
+	h.records = append(h.records, r)
+	return nil
+}
+
+func (h *captureHandler) WithAttrs([]slog.Attr) slog.Handler { return h }
+
+func (h *captureHandler) WithGroup(string) slog.Handler { return h }
+
+func TestRecoveryHandler_SlogLogger(t *testing.T) {
+	capture := &captureHandler{}
+	logger := NewSlogLogger(slog.New(capture))
+
+	handler := RecoveryHandler(logger, true)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		panic("unexpected error")
+	}))
+
+	req := httptest.NewRequest(http.MethodPost, "/users/123", nil)
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusInternalServerError {
+		t.Errorf("unexpected status code: %v", rec.Code)
+	}
+
+	if len(capture.records) != 1 {
+		t.Fatalf("expected 1 log record, got %d", len(capture.records))
+	}
+
+	record := capture.records[0]
+	if record.Level != slog.LevelError {
+		t.Errorf("expected level %v, got %v", slog.LevelError, record.Level)
+	}
+
+	attrs := make(map[string]string)
+	record.Attrs(func(a slog.Attr) bool {
+		attrs[a.Key] = a.Value.String()
+		return true
+	})
+
+	expected := map[string]string{
+		"error":  "unexpected error",
+		"method": http.MethodPost,
+		"path":   "/users/123",
+	}
+	for k, v := range expected {
+		if attrs[k] != v {
+			t.Errorf("expected attribute %s=%q, got %q", k, v, attrs[k])
+		}
+	}
+	if attrs["stack"] == "" {
+		t.Errorf("expected stack attribute to be logged")
+	}
+}
+
+func TestSlogLogger_Println(t *testing.T) {
+	capture := &captureHandler{}
+	logger := NewSlogLogger(slog.New(capture))
+
+	logger.Println("hello", "world")
+
+	if len(capture.records) != 1 {
+		t.Fatalf("expected 1 log record, got %d", len(capture.records))
+	}
+	if got := capture.records[0].Message; got != "hello world" {
+		t.Errorf("expected message %q, got %q", "hello world", got)
+	}
+}
@@ -0,0 +1,70 @@
+package middleware
+
+import (
+	"net/http"
+)
+
+/*
+MapStatus is a middleware that rewrites response status codes according to the
+given mapping before they reach the client. It is useful for legacy clients that
+cannot handle certain codes, for example mapping 204 No Content to 200 OK.
+
+If the handler never calls WriteHeader, the implicit 200 status is also subject
+to the mapping. A body written by the handler is passed through when the mapped
+status is in the same class, such as 204 to 200. When the class changes, such as
+404 to 200, the body describes the original status, so it is dropped along with
+its Content-Type and Content-Length headers.
+
+Usage:
+
+	r := muxer.NewRouter()
+	r.Use(middleware.MapStatus(map[int]int{
+		http.StatusNoContent: http.StatusOK,
+	}))
+*/
+func MapStatus(mapping map[int]int) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			sw := &statusMappingWriter{ResponseWriter: w, mapping: mapping}
+			next.ServeHTTP(sw, r)
+			if !sw.wroteHeader {
+				sw.WriteHeader(http.StatusOK)
+			}
+		})
+	}
+}
+
+// A statusMappingWriter wraps an http.ResponseWriter and rewrites the status
+// code passed to WriteHeader according to its mapping.
+type statusMappingWriter struct {
+	http.ResponseWriter
+	mapping     map[int]int
+	wroteHeader bool
+	dropBody    bool
+}
+
+func (w *statusMappingWriter) WriteHeader(code int) {
+	if w.wroteHeader {
+		return
+	}
+	w.wroteHeader = true
+	if mapped, ok := w.mapping[code]; ok {
+		if mapped/100 != code/100 {
+			w.dropBody = true
+			w.Header().Del("Content-Type")
+			w.Header().Del("Content-Length")
+		}
+		code = mapped
+	}
+	w.ResponseWriter.WriteHeader(code)
+}
+
+func (w *statusMappingWriter) Write(b []byte) (int, error) {
+	if !w.wroteHeader {
+		w.WriteHeader(http.StatusOK)
+	}
+	if w.dropBody {
+		return len(b), nil
+	}
+	return w.ResponseWriter.Write(b)
+}
@@ -0,0 +1,88 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestMapStatus(t *testing.T) {
+	tests := []struct {
+		name         string
+		mapping      map[int]int
+		handler      http.HandlerFunc
+		expectedCode int
+		expectedBody string
+	}{
+		{
+			name:    "maps 204 to 200",
+			mapping: map[int]int{http.StatusNoContent: http.StatusOK},
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(http.StatusNoContent)
+			},
+			expectedCode: http.StatusOK,
+		},
+		{
+			name:    "maps 404 to 200 and drops body",
+			mapping: map[int]int{http.StatusNotFound: http.StatusOK},
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+				w.WriteHeader(http.StatusNotFound)
+				w.Write([]byte("missing")) // nolint: errcheck
+			},
+			expectedCode: http.StatusOK,
+		},
+		{
+			name:    "maps 201 to 200 and keeps body",
+			mapping: map[int]int{http.StatusCreated: http.StatusOK},
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(http.StatusCreated)
+				w.Write([]byte("created")) // nolint: errcheck
+			},
+			expectedCode: http.StatusOK,
+			expectedBody: "created",
+		},
+		{
+			name:    "unmapped status passes through",
+			mapping: map[int]int{http.StatusNoContent: http.StatusOK},
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(http.StatusCreated)
+			},
+			expectedCode: http.StatusCreated,
+		},
+		{
+			name:    "implicit 200 on write is mapped",
+			mapping: map[int]int{http.StatusOK: http.StatusAccepted},
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				w.Write([]byte("ok")) // nolint: errcheck
+			},
+			expectedCode: http.StatusAccepted,
+			expectedBody: "ok",
+		},
+		{
+			name:         "implicit 200 without write is mapped",
+			mapping:      map[int]int{http.StatusOK: http.StatusAccepted},
+			handler:      func(w http.ResponseWriter, r *http.Request) {},
+			expectedCode: http.StatusAccepted,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			rec := httptest.NewRecorder()
+
+			MapStatus(tc.mapping)(tc.handler).ServeHTTP(rec, req)
+
+			if rec.Code != tc.expectedCode {
+				t.Errorf("expected status code %d, got %d", tc.expectedCode, rec.Code)
+			}
+			if rec.Body.String() != tc.expectedBody {
+				t.Errorf("expected body %q, got %q", tc.expectedBody, rec.Body.String())
+			}
+			if tc.expectedBody == "" && rec.Header().Get("Content-Type") != "" {
+				t.Errorf("expected no Content-Type, got %q", rec.Header().Get("Content-Type"))
+			}
+		})
+	}
+}
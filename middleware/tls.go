@@ -0,0 +1,86 @@
+package middleware
+
+import (
+	"crypto/tls"
+	"net/http"
+)
+
+type tlsVersionConfig struct {
+	RejectPlaintext bool
+}
+
+// TLSVersionOption is a function that modifies the RequireTLSVersion configuration.
+type TLSVersionOption func(*tlsVersionConfig)
+
+// WithRejectPlaintext makes RequireTLSVersion reject requests that were not received
+// over TLS at all, instead of passing them through.
+func WithRejectPlaintext() TLSVersionOption {
+	return func(cfg *tlsVersionConfig) {
+		cfg.RejectPlaintext = true
+	}
+}
+
+/*
+RequireTLSVersion returns a middleware that rejects requests received over a TLS
+version lower than min, such as tls.VersionTLS12, with 426 Upgrade Required and
+an Upgrade header naming the required protocol, e.g. "TLS/1.2, HTTP/1.1". It is
+meant as defense in depth next to the MinVersion of the server's tls.Config.
+
+Requests that were not received over TLS, for example behind a TLS-terminating
+proxy, are passed through unless WithRejectPlaintext is given.
+
+Usage:
+
+	r := muxer.NewRouter()
+	r.Use(middleware.RequireTLSVersion(tls.VersionTLS12))
+*/
+func RequireTLSVersion(min uint16, options ...TLSVersionOption) func(http.Handler) http.Handler {
+	cfg := &tlsVersionConfig{}
+	for _, option := range options {
+		option(cfg)
+	}
+	upgrade := tlsProtocol(min) + ", HTTP/1.1"
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.TLS == nil {
+				if cfg.RejectPlaintext {
+					requireUpgrade(w, upgrade)
+					http.Error(w, "TLS required", http.StatusUpgradeRequired)
+					return
+				}
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			if r.TLS.Version < min {
+				requireUpgrade(w, upgrade)
+				http.Error(w, "Unsupported TLS version", http.StatusUpgradeRequired)
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// requireUpgrade sets the headers of a 426 response asking the client to upgrade
+// to the given protocols.
+func requireUpgrade(w http.ResponseWriter, upgrade string) {
+	w.Header().Set("Upgrade", upgrade)
+	w.Header().Set("Connection", "Upgrade")
+}
+
+// tlsProtocol returns the Upgrade protocol token for a TLS version, falling back to
+// TLS/1.2 for versions it does not know.
+func tlsProtocol(version uint16) string {
+	switch version {
+	case tls.VersionTLS10:
+		return "TLS/1.0"
+	case tls.VersionTLS11:
+		return "TLS/1.1"
+	case tls.VersionTLS13:
+		return "TLS/1.3"
+	}
+	return "TLS/1.2"
+}
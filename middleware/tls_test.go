@@ -0,0 +1,48 @@
+package middleware
+
+import (
+	"crypto/tls"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestRequireTLSVersion(t *testing.T) {
+	tests := []struct {
+		name         string
+		state        *tls.ConnectionState
+		options      []TLSVersionOption
+		expectedCode int
+	}{
+		{"TLS 1.0 rejected", &tls.ConnectionState{Version: tls.VersionTLS10}, nil, http.StatusUpgradeRequired},
+		{"TLS 1.1 rejected", &tls.ConnectionState{Version: tls.VersionTLS11}, nil, http.StatusUpgradeRequired},
+		{"TLS 1.2 accepted", &tls.ConnectionState{Version: tls.VersionTLS12}, nil, http.StatusOK},
+		{"TLS 1.3 accepted", &tls.ConnectionState{Version: tls.VersionTLS13}, nil, http.StatusOK},
+		{"plaintext passed through", nil, nil, http.StatusOK},
+		{"plaintext rejected", nil, []TLSVersionOption{WithRejectPlaintext()}, http.StatusUpgradeRequired},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			req.TLS = tc.state
+			rec := httptest.NewRecorder()
+
+			handler := RequireTLSVersion(tls.VersionTLS12, tc.options...)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(http.StatusOK)
+			}))
+			handler.ServeHTTP(rec, req)
+
+			if rec.Code != tc.expectedCode {
+				t.Errorf("expected status code %d, got %d", tc.expectedCode, rec.Code)
+			}
+			expectedUpgrade := ""
+			if tc.expectedCode == http.StatusUpgradeRequired {
+				expectedUpgrade = "TLS/1.2, HTTP/1.1"
+			}
+			if upgrade := rec.Header().Get("Upgrade"); upgrade != expectedUpgrade {
+				t.Errorf("expected Upgrade header %q, got %q", expectedUpgrade, upgrade)
+			}
+		})
+	}
+}
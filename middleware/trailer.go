@@ -0,0 +1,37 @@
+package middleware
+
+import (
+	"net/http"
+	"time"
+)
+
+// DurationTrailerHeader is the name of the trailer set by DurationTrailer.
+const DurationTrailerHeader = "X-Duration"
+
+/*
+DurationTrailer is a middleware that sends the total handler duration as an HTTP
+trailer. It declares "Trailer: X-Duration" before the handler runs, times the
+handler, and sets the X-Duration trailer once the body has been written.
+
+Trailers are only supported by HTTP/1.1 chunked responses and HTTP/2, so requests
+made with an older protocol are passed through untouched. A handler that sets
+Content-Length disables chunking and therefore the trailer as well.
+
+Usage:
+
+	r := muxer.NewRouter()
+	r.Use(middleware.DurationTrailer)
+*/
+func DurationTrailer(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !r.ProtoAtLeast(1, 1) {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		w.Header().Add("Trailer", DurationTrailerHeader)
+		start := time.Now()
+		next.ServeHTTP(w, r)
+		w.Header().Set(DurationTrailerHeader, time.Since(start).String())
+	})
+}
@@ -0,0 +1,57 @@
+package middleware
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestDurationTrailer(t *testing.T) {
+	server := httptest.NewServer(DurationTrailer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("hello")) // nolint: errcheck
+		w.(http.Flusher).Flush()
+		time.Sleep(5 * time.Millisecond)
+		w.Write([]byte(" world")) // nolint: errcheck
+	})))
+	defer server.Close()
+
+	resp, err := http.Get(server.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(body) != "hello world" {
+		t.Errorf("expected body %q, got %q", "hello world", string(body))
+	}
+
+	value := resp.Trailer.Get(DurationTrailerHeader)
+	if value == "" {
+		t.Fatalf("expected %s trailer to be set", DurationTrailerHeader)
+	}
+	duration, err := time.ParseDuration(value)
+	if err != nil {
+		t.Fatalf("expected a duration trailer value, got %q: %v", value, err)
+	}
+	if duration < 5*time.Millisecond {
+		t.Errorf("expected duration of at least 5ms, got %v", duration)
+	}
+}
+
+func TestDurationTrailer_HTTP10(t *testing.T) {
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Proto, req.ProtoMajor, req.ProtoMinor = "HTTP/1.0", 1, 0
+	rec := httptest.NewRecorder()
+
+	DurationTrailer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {})).ServeHTTP(rec, req)
+
+	if got := rec.Header().Get("Trailer"); got != "" {
+		t.Errorf("expected no Trailer header for HTTP/1.0, got %q", got)
+	}
+}
@@ -0,0 +1,38 @@
+package middleware
+
+import "net/http"
+
+/*
+MaxURLLength returns a middleware that rejects requests whose URL, as sent in the
+request line, is longer than max bytes. Overly long URLs are rejected with 414 URI
+Too Long, unless tooLong is non-nil, in which case it handles the request instead,
+for example to respond with a JSON body or a different status.
+
+Usage:
+
+	r := muxer.NewRouter()
+	r.Use(middleware.MaxURLLength(2048, nil))
+*/
+func MaxURLLength(max int, tooLong http.Handler) func(http.Handler) http.Handler {
+	if tooLong == nil {
+		tooLong = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			http.Error(w, "URI Too Long", http.StatusRequestURITooLong)
+		})
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			uri := r.RequestURI
+			if uri == "" {
+				uri = r.URL.RequestURI()
+			}
+
+			if len(uri) > max {
+				tooLong.ServeHTTP(w, r)
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
@@ -0,0 +1,50 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestMaxURLLength(t *testing.T) {
+	custom := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte(`{"error":"url too long"}`)) // nolint: errcheck
+	})
+
+	longPath := "/" + strings.Repeat("a", 64)
+
+	tests := []struct {
+		name         string
+		tooLong      http.Handler
+		target       string
+		expectedCode int
+		expectedBody string
+	}{
+		{"short URL", nil, "/short", http.StatusOK, "ok"},
+		{"long URL default handler", nil, longPath, http.StatusRequestURITooLong, "URI Too Long\n"},
+		{"long query default handler", nil, "/q?" + strings.Repeat("x", 64), http.StatusRequestURITooLong, "URI Too Long\n"},
+		{"long URL custom handler", custom, longPath, http.StatusBadRequest, `{"error":"url too long"}`},
+		{"short URL custom handler", custom, "/short", http.StatusOK, "ok"},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			handler := MaxURLLength(32, tc.tooLong)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.Write([]byte("ok")) // nolint: errcheck
+			}))
+
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, tc.target, nil))
+
+			if rec.Code != tc.expectedCode {
+				t.Errorf("expected status code %d, got %d", tc.expectedCode, rec.Code)
+			}
+			if rec.Body.String() != tc.expectedBody {
+				t.Errorf("expected body %q, got %q", tc.expectedBody, rec.Body.String())
+			}
+		})
+	}
+}
@@ -1,7 +1,12 @@
 package muxer
 
 import (
+	"context"
 	"net/http"
+	"strings"
+	"time"
+
+	"github.com/shellfu/muxer/middleware"
 )
 
 /*
@@ -21,6 +26,30 @@ func WithNotFoundHandler(handler http.Handler) RouterOption {
 	}
 }
 
+/*
+WithMethodNotAllowedHandler option takes a http.Handler that will be set as the
+MethodNotAllowedHandler of the Router. This handler will be executed when the
+Router receives a request for a known path with a method that has no route.
+Any http.Handler may be passed, not only an http.HandlerFunc.
+*/
+func WithMethodNotAllowedHandler(handler http.Handler) RouterOption {
+	return func(r *Router) {
+		r.MethodNotAllowedHandler = handler.ServeHTTP
+	}
+}
+
+/*
+WithPanicHandler option sets the PanicHandler of the Router. It is called with the
+recovered value when a panic occurs while the Router dispatches a request, instead
+of answering it with 500 Internal Server Error. It takes precedence over recovery
+enabled with WithRecovery, which is then bypassed.
+*/
+func WithPanicHandler(fn func(http.ResponseWriter, *http.Request, interface{})) RouterOption {
+	return func(r *Router) {
+		r.PanicHandler = fn
+	}
+}
+
 /*
 WithMaxRequestBodySize option sets the maximum size of the request body that
 the Router can handle. This option can be used to prevent denial-of-service
@@ -31,3 +60,194 @@ func WithMaxRequestBodySize(size int64) RouterOption {
 		r.MaxRequestBodySize = size
 	}
 }
+
+/*
+WithNotFoundMessage option sets a NotFoundHandler that responds to unknown paths
+with the given status code, body and content type. It is a middle ground between
+the default "404 page not found" text and a fully custom NotFoundHandler.
+*/
+func WithNotFoundMessage(status int, body string, contentType string) RouterOption {
+	return func(r *Router) {
+		r.NotFoundHandler = func(w http.ResponseWriter, req *http.Request) {
+			w.Header().Set("Content-Type", contentType)
+			w.Header().Set("X-Content-Type-Options", "nosniff")
+			w.WriteHeader(status)
+			w.Write([]byte(body)) // nolint: errcheck
+		}
+	}
+}
+
+/*
+WithHeadLiveness option makes the Router answer HEAD requests to the given paths
+with 200 OK before any route matching takes place, as a liveness signal for
+monitoring tools. If no paths are given, HEAD requests to any path are answered.
+Requests with other methods are routed normally.
+*/
+func WithHeadLiveness(paths ...string) RouterOption {
+	return func(r *Router) {
+		r.headLiveness = true
+		r.headLivenessPaths = make(map[string]bool, len(paths))
+		for _, path := range paths {
+			r.headLivenessPaths[path] = true
+		}
+	}
+}
+
+/*
+WithErrorContentType option sets the content type errors returned from HandleErr
+handlers are rendered in. With a JSON type such as "application/json", errors are
+written as {"error": "message"}; otherwise they are written as plain text.
+It applies to Abort called by route handlers as well. Subrouters and routers
+created with CloneConfig inherit it.
+*/
+func WithErrorContentType(contentType string) RouterOption {
+	return func(r *Router) {
+		r.errorContentType = contentType
+	}
+}
+
+/*
+WithRecovery option installs panic recovery at the router level, so a panic anywhere
+during dispatch, in middleware, handlers or subrouters, is recovered, logged and
+answered with 500 Internal Server Error without registering the recovery middleware.
+It takes the same arguments as middleware.RecoveryHandler, so a renderer set with
+middleware.WithPanicRenderer can be shared with recovery middleware registered
+elsewhere.
+
+A handler panicking with an HTTPError, as in panic(muxer.HTTPError{Status: 403}),
+is answered with its status and message instead of 500. Other panic values are
+classified by middleware.StatusCodeClassifier, unless options replace the classifier.
+*/
+func WithRecovery(logger middleware.RecoveryLogger, printStack bool, options ...middleware.RecoveryOption) RouterOption {
+	options = append([]middleware.RecoveryOption{middleware.WithPanicClassifier(classifyPanic)}, options...)
+	return func(r *Router) {
+		r.recovery = middleware.RecoveryHandler(logger, printStack, options...)
+	}
+}
+
+/*
+WithAutoOptions option makes the Router answer OPTIONS requests to paths with
+registered routes, but no OPTIONS route of their own, with 204 No Content and an
+Allow header listing the methods available on the path. OPTIONS is then also listed
+in the Allow header of 405 responses. Subrouters inherit the behavior, so an OPTIONS
+probe on "/api/users/1" lists the methods registered on the "/api" subrouter.
+*/
+func WithAutoOptions() RouterOption {
+	return func(r *Router) {
+		r.autoOptions = true
+	}
+}
+
+/*
+WithAutoHead option makes the Router serve HEAD requests to paths without a HEAD
+route with their GET route. The response carries the headers and status code the
+GET handler writes, including Content-Length, without the body. HEAD is then also
+listed in the Allow header of 405 responses for GET routes. Subrouters inherit the
+behavior.
+*/
+func WithAutoHead() RouterOption {
+	return func(r *Router) {
+		r.autoHead = true
+	}
+}
+
+/*
+WithCaseInsensitive option makes routes match request paths regardless of the case
+of their literal segments, so "/Users/123" matches a route registered as
+"/users/:id". It only affects literal segments: parameter values are captured as
+sent, in their original case. Subrouter prefixes are still matched exactly.
+Subrouters inherit the behavior.
+*/
+func WithCaseInsensitive() RouterOption {
+	return func(r *Router) {
+		r.caseInsensitive = true
+	}
+}
+
+/*
+WithRouteContextDecorator option sets a function that derives the request context
+once a route has been matched, after the parameters and the route are stored in it
+and before middleware and the handler run. It lets applications embedding the
+Router attach values derived from the route, such as a resolved handler name:
+
+	muxer.WithRouteContextDecorator(func(ctx context.Context, route *muxer.Route) context.Context {
+	    template, _ := route.PathTemplate()
+	    return context.WithValue(ctx, handlerNameKey, names[template])
+	})
+
+Subrouters created after the option is applied inherit the decorator.
+*/
+func WithRouteContextDecorator(decorator func(ctx context.Context, route *Route) context.Context) RouterOption {
+	return func(r *Router) {
+		r.routeContextDecorator = decorator
+	}
+}
+
+/*
+WithMaxPathSegments option makes the Router reject requests whose path has more than
+n segments with 414 URI Too Long before any matching takes place. It is a cheap
+guard against abusive, deeply nested paths. Leading and trailing slashes are
+ignored, so "/a/b/c/" has three segments.
+*/
+func WithMaxPathSegments(n int) RouterOption {
+	return func(r *Router) {
+		r.maxPathSegments = n
+	}
+}
+
+/*
+WithRetryAfter option makes the Router send a Retry-After header with the duration,
+rounded up to whole seconds, on the 503 Service Unavailable responses it generates,
+such as those of routes shedding load with MaxConcurrent or HandleErr handlers
+returning a 503 HTTPError. Subrouters created after the option is applied inherit it.
+*/
+func WithRetryAfter(d time.Duration) RouterOption {
+	return func(r *Router) {
+		r.retryAfter = d
+	}
+}
+
+/*
+WithRejectUnknownLength option makes the Router reject requests whose body has no
+declared length, such as chunked uploads, with 411 Length Required before routing.
+Combined with WithMaxRequestBodySize, every oversized body is then rejected early,
+from its Content-Length, instead of failing while it is being read.
+*/
+func WithRejectUnknownLength() RouterOption {
+	return func(r *Router) {
+		r.rejectUnknownLength = true
+	}
+}
+
+/*
+WithDiscovery option makes requests under the path prefix that match no route, and
+would otherwise be answered by the NotFoundHandler, be passed to handler together
+with the routes of the router whose templates fall under the prefix, as returned
+by Routes. It lets a self-describing API answer unknown paths with a discovery
+document linking its endpoints. Requests outside the prefix, and requests whose
+path matches a route registered for other methods, are not affected. Subrouters
+inherit the option, so unmatched requests they receive are answered the same way.
+
+	muxer.WithDiscovery("/api", func(w http.ResponseWriter, r *http.Request, routes []muxer.RouteInfo) {
+	    w.Header().Set("Content-Type", "application/json")
+	    w.WriteHeader(http.StatusNotFound)
+	    json.NewEncoder(w).Encode(routes)
+	})
+*/
+func WithDiscovery(prefix string, handler func(w http.ResponseWriter, r *http.Request, routes []RouteInfo)) RouterOption {
+	return func(r *Router) {
+		r.discovery = &discovery{prefix: strings.TrimSuffix(prefix, "/"), handler: handler, router: r}
+	}
+}
+
+/*
+WithWildcardQuery option makes wildcard routes include the raw query string in the
+captured "path" parameter, so a request for "/proxy/foo?k=v" on "/proxy/*" captures
+"foo?k=v" instead of "foo". Routes without a wildcard are unaffected. Subrouters
+created after the option is applied inherit it.
+*/
+func WithWildcardQuery() RouterOption {
+	return func(r *Router) {
+		r.wildcardQuery = true
+	}
+}
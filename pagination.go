@@ -0,0 +1,63 @@
+package muxer
+
+import (
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+/*
+SetPaginationLinks sets an RFC 5988 Link header on the response describing the
+first, prev, next and last pages of a paginated collection.
+
+Each link is built from base with its "page" and "per_page" query parameters
+replaced, so any other query parameters on base are preserved. The first page
+has no prev link and the last page has no next link. A page past the last page
+has no next link and its prev link points to the last page. If perPage is not
+positive no header is set.
+
+	Example usage:
+	  router.HandleRoute("GET", "/users", func(w http.ResponseWriter, r *http.Request) {
+	      muxer.SetPaginationLinks(w, r.URL, 2, 25, 110)
+	      // Link: </users?page=1&per_page=25>; rel="first", </users?page=1&per_page=25>; rel="prev", ...
+	  })
+*/
+func SetPaginationLinks(w http.ResponseWriter, base *url.URL, page, perPage, total int) {
+	if perPage <= 0 {
+		return
+	}
+
+	lastPage := (total + perPage - 1) / perPage
+	if lastPage < 1 {
+		lastPage = 1
+	}
+	if page < 1 {
+		page = 1
+	}
+
+	links := []string{paginationLink(base, 1, perPage, "first")}
+	if page > 1 {
+		prev := page - 1
+		if prev > lastPage {
+			prev = lastPage
+		}
+		links = append(links, paginationLink(base, prev, perPage, "prev"))
+	}
+	if page < lastPage {
+		links = append(links, paginationLink(base, page+1, perPage, "next"))
+	}
+	links = append(links, paginationLink(base, lastPage, perPage, "last"))
+
+	w.Header().Set("Link", strings.Join(links, ", "))
+}
+
+// paginationLink formats a single Link header value for the given page.
+func paginationLink(base *url.URL, page, perPage int, rel string) string {
+	u := *base
+	query := u.Query()
+	query.Set("page", strconv.Itoa(page))
+	query.Set("per_page", strconv.Itoa(perPage))
+	u.RawQuery = query.Encode()
+	return "<" + u.String() + `>; rel="` + rel + `"`
+}
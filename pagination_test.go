@@ -0,0 +1,78 @@
+package muxer
+
+import (
+	"net/http/httptest"
+	"net/url"
+	"testing"
+)
+
+func TestSetPaginationLinks(t *testing.T) {
+	base, err := url.Parse("https://example.com/users?sort=name")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name     string
+		page     int
+		perPage  int
+		total    int
+		expected string
+	}{
+		{
+			name:    "middle page",
+			page:    2,
+			perPage: 10,
+			total:   35,
+			expected: `<https://example.com/users?page=1&per_page=10&sort=name>; rel="first", ` +
+				`<https://example.com/users?page=1&per_page=10&sort=name>; rel="prev", ` +
+				`<https://example.com/users?page=3&per_page=10&sort=name>; rel="next", ` +
+				`<https://example.com/users?page=4&per_page=10&sort=name>; rel="last"`,
+		},
+		{
+			name:    "first page has no prev",
+			page:    1,
+			perPage: 10,
+			total:   35,
+			expected: `<https://example.com/users?page=1&per_page=10&sort=name>; rel="first", ` +
+				`<https://example.com/users?page=2&per_page=10&sort=name>; rel="next", ` +
+				`<https://example.com/users?page=4&per_page=10&sort=name>; rel="last"`,
+		},
+		{
+			name:    "last page has no next",
+			page:    4,
+			perPage: 10,
+			total:   35,
+			expected: `<https://example.com/users?page=1&per_page=10&sort=name>; rel="first", ` +
+				`<https://example.com/users?page=3&per_page=10&sort=name>; rel="prev", ` +
+				`<https://example.com/users?page=4&per_page=10&sort=name>; rel="last"`,
+		},
+		{
+			name:    "page past the last page links back to the last page",
+			page:    9,
+			perPage: 10,
+			total:   35,
+			expected: `<https://example.com/users?page=1&per_page=10&sort=name>; rel="first", ` +
+				`<https://example.com/users?page=4&per_page=10&sort=name>; rel="prev", ` +
+				`<https://example.com/users?page=4&per_page=10&sort=name>; rel="last"`,
+		},
+		{
+			name:     "invalid page size sets no header",
+			page:     1,
+			perPage:  0,
+			total:    35,
+			expected: "",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			SetPaginationLinks(w, base, tc.page, tc.perPage, tc.total)
+
+			if got := w.Header().Get("Link"); got != tc.expected {
+				t.Errorf("unexpected Link header:\nexpected=%s\nactual=%s", tc.expected, got)
+			}
+		})
+	}
+}
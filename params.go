@@ -0,0 +1,80 @@
+package muxer
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+/*
+ParamInt returns the path parameter name of the request parsed as an int. It returns
+an error naming the parameter if it is absent or not a valid integer, suitable for a
+400 response.
+
+	Example usage:
+	  id, err := muxer.ParamInt(r, "id")
+	  if err != nil {
+	      http.Error(w, err.Error(), http.StatusBadRequest)
+	      return
+	  }
+*/
+func ParamInt(req *http.Request, name string) (int, error) {
+	value, err := param(req, name)
+	if err != nil {
+		return 0, err
+	}
+	n, err := strconv.Atoi(value)
+	if err != nil {
+		return 0, fmt.Errorf("param %q is not an integer: %q", name, value)
+	}
+	return n, nil
+}
+
+// ParamInt returns the path parameter name of the request parsed as an int, see the
+// ParamInt function.
+func (r *Router) ParamInt(req *http.Request, name string) (int, error) {
+	return ParamInt(req, name)
+}
+
+/*
+ParamBool returns the path parameter name of the request parsed as a bool. The
+values true, false, 1, 0, yes and no are accepted, regardless of case. It returns
+an error naming the parameter if it is absent or not one of those values.
+*/
+func ParamBool(req *http.Request, name string) (bool, error) {
+	value, err := param(req, name)
+	if err != nil {
+		return false, err
+	}
+	switch strings.ToLower(value) {
+	case "true", "1", "yes":
+		return true, nil
+	case "false", "0", "no":
+		return false, nil
+	}
+	return false, fmt.Errorf("param %q is not a boolean: %q", name, value)
+}
+
+// ParamFloat returns the path parameter name of the request parsed as a float64. It
+// returns an error naming the parameter if it is absent or not a valid number.
+func ParamFloat(req *http.Request, name string) (float64, error) {
+	value, err := param(req, name)
+	if err != nil {
+		return 0, err
+	}
+	f, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		return 0, fmt.Errorf("param %q is not a number: %q", name, value)
+	}
+	return f, nil
+}
+
+// param returns the path parameter name of the request, or an error if it is absent.
+func param(req *http.Request, name string) (string, error) {
+	value, ok := Params(req)[name]
+	if !ok {
+		return "", fmt.Errorf("missing param %q", name)
+	}
+	return value, nil
+}
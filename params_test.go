@@ -0,0 +1,104 @@
+package muxer
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestParamInt(t *testing.T) {
+	router := NewRouter()
+
+	var (
+		id  int
+		err error
+	)
+	router.HandleRoute(http.MethodGet, "/users/:id", func(w http.ResponseWriter, r *http.Request) {
+		id, err = router.ParamInt(r, "id")
+	})
+	router.HandleRoute(http.MethodGet, "/users", func(w http.ResponseWriter, r *http.Request) {
+		id, err = ParamInt(r, "id")
+	})
+
+	tests := []struct {
+		path          string
+		expected      int
+		expectedError string
+	}{
+		{"/users/42", 42, ""},
+		{"/users/-7", -7, ""},
+		{"/users/abc", 0, `param "id" is not an integer: "abc"`},
+		{"/users", 0, `missing param "id"`},
+	}
+
+	for _, tc := range tests {
+		id, err = 0, nil
+		router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, tc.path, nil))
+
+		if id != tc.expected {
+			t.Errorf("unexpected value for %s: expected=%d, actual=%d", tc.path, tc.expected, id)
+		}
+		if tc.expectedError == "" && err != nil {
+			t.Errorf("unexpected error for %s: %v", tc.path, err)
+		}
+		if tc.expectedError != "" && (err == nil || err.Error() != tc.expectedError) {
+			t.Errorf("unexpected error for %s: expected=%q, actual=%v", tc.path, tc.expectedError, err)
+		}
+	}
+}
+
+func TestParamBoolAndFloat(t *testing.T) {
+	router := NewRouter()
+
+	var (
+		b       bool
+		f       float64
+		boolErr error
+		fltErr  error
+	)
+	router.HandleRoute(http.MethodGet, "/flags/:value", func(w http.ResponseWriter, r *http.Request) {
+		b, boolErr = ParamBool(r, "value")
+		f, fltErr = ParamFloat(r, "value")
+	})
+
+	tests := []struct {
+		value         string
+		expectedBool  bool
+		boolError     string
+		expectedFloat float64
+		floatError    string
+	}{
+		{"true", true, "", 0, `param "value" is not a number: "true"`},
+		{"YES", true, "", 0, `param "value" is not a number: "YES"`},
+		{"No", false, "", 0, `param "value" is not a number: "No"`},
+		{"1", true, "", 1, ""},
+		{"0", false, "", 0, ""},
+		{"2.5", false, `param "value" is not a boolean: "2.5"`, 2.5, ""},
+		{"-0.75", false, `param "value" is not a boolean: "-0.75"`, -0.75, ""},
+	}
+
+	for _, tc := range tests {
+		router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/flags/"+tc.value, nil))
+
+		if b != tc.expectedBool {
+			t.Errorf("unexpected bool for %q: expected=%t, actual=%t", tc.value, tc.expectedBool, b)
+		}
+		if (boolErr == nil) != (tc.boolError == "") || (boolErr != nil && boolErr.Error() != tc.boolError) {
+			t.Errorf("unexpected bool error for %q: expected=%q, actual=%v", tc.value, tc.boolError, boolErr)
+		}
+		if f != tc.expectedFloat {
+			t.Errorf("unexpected float for %q: expected=%g, actual=%g", tc.value, tc.expectedFloat, f)
+		}
+		if (fltErr == nil) != (tc.floatError == "") || (fltErr != nil && fltErr.Error() != tc.floatError) {
+			t.Errorf("unexpected float error for %q: expected=%q, actual=%v", tc.value, tc.floatError, fltErr)
+		}
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	if _, err := ParamBool(req, "missing"); err == nil || err.Error() != `missing param "missing"` {
+		t.Errorf("unexpected error for missing bool param: %v", err)
+	}
+	if _, err := ParamFloat(req, "missing"); err == nil || err.Error() != `missing param "missing"` {
+		t.Errorf("unexpected error for missing float param: %v", err)
+	}
+}
@@ -0,0 +1,133 @@
+package muxer
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+/*
+Route registers handler for a pattern combining an HTTP method and a path in a
+single string, mirroring the patterns of the Go 1.22 net/http.ServeMux:
+
+	router.Route("GET /users/:id", showUser)
+	router.Route("POST /users", createUser)
+	router.Route("/health", health)      // no method: GET
+	router.Route("* /proxy/*", proxy)    // "*": any method, see HandleAny
+
+An error is returned for malformed patterns, such as an empty pattern, a path not
+starting with "/" or a method token that is not an uppercase HTTP method.
+*/
+func (r *Router) Route(pattern string, handler http.HandlerFunc) (*Route, error) {
+	method, path, err := parsePattern(pattern)
+	if err != nil {
+		return nil, err
+	}
+	return r.HandleRoute(method, path, handler), nil
+}
+
+/*
+HandleStd registers an http.Handler for a net/http.ServeMux style pattern, easing
+migration from the standard library mux. Wildcards are translated to path
+parameters, so "{id}" becomes ":id" and a trailing "{rest...}" becomes the greedy
+parameter ":rest{greedy}"; both are read with Params as usual. A trailing "{$}" is
+dropped, since muxer routes always match the full path. As with ServeMux, a pattern
+without a method matches any method, and a GET pattern matches HEAD requests too.
+
+	// Previously: mux.Handle("GET /users/{id}", usersHandler)
+	router.HandleStd("GET /users/{id}", usersHandler)
+
+Host patterns are not supported, and a pattern ending in "/" matches only that
+path rather than the whole subtree; use a "*" wildcard route for the latter.
+*/
+func (r *Router) HandleStd(pattern string, handler http.Handler) (*Route, error) {
+	method, path, err := parsePattern(pattern)
+	if err != nil {
+		return nil, err
+	}
+	path, err = translateStdPath(path)
+	if err != nil {
+		return nil, fmt.Errorf("invalid route pattern %q: %w", pattern, err)
+	}
+	if len(strings.Fields(pattern)) == 1 {
+		method = MethodAny
+	}
+
+	route := r.Handle(method, path, handler)
+	if method == http.MethodGet {
+		r.handleHead(route, path)
+	}
+	return route, nil
+}
+
+// translateStdPath rewrites the {name} and {name...} wildcards of a ServeMux path
+// into muxer path parameters.
+func translateStdPath(path string) (string, error) {
+	path = strings.TrimSuffix(path, "{$}")
+
+	var b strings.Builder
+	for {
+		start := strings.IndexByte(path, '{')
+		if start < 0 {
+			b.WriteString(path)
+			return b.String(), nil
+		}
+		end := strings.IndexByte(path[start:], '}')
+		if end < 0 {
+			return "", fmt.Errorf("unclosed wildcard in %q", path)
+		}
+		end += start
+
+		b.WriteString(path[:start])
+		name := path[start+1 : end]
+		greedy := strings.HasSuffix(name, "...")
+		name = strings.TrimSuffix(name, "...")
+		if name == "" {
+			return "", fmt.Errorf("empty wildcard name in %q", path)
+		}
+		if greedy && end != len(path)-1 {
+			return "", fmt.Errorf("wildcard {%s...} must be at the end of %q", name, path)
+		}
+
+		b.WriteString(":" + name)
+		if greedy {
+			b.WriteString(greedyModifier)
+		}
+		path = path[end+1:]
+	}
+}
+
+// parsePattern splits a "METHOD /path" pattern into its method and path. Patterns
+// without a method default to GET.
+func parsePattern(pattern string) (method, path string, err error) {
+	fields := strings.Fields(pattern)
+	switch len(fields) {
+	case 1:
+		method, path = http.MethodGet, fields[0]
+	case 2:
+		method, path = fields[0], fields[1]
+	default:
+		return "", "", fmt.Errorf("invalid route pattern %q: expected \"[METHOD] /path\"", pattern)
+	}
+
+	if !isMethodToken(method) {
+		return "", "", fmt.Errorf("invalid route pattern %q: invalid method %q", pattern, method)
+	}
+	if !strings.HasPrefix(path, "/") {
+		return "", "", fmt.Errorf("invalid route pattern %q: path must start with \"/\"", pattern)
+	}
+	return method, path, nil
+}
+
+// isMethodToken reports whether method is MethodAny or consists of uppercase letters only.
+func isMethodToken(method string) bool {
+	if method == MethodAny {
+		return true
+	}
+	for _, c := range method {
+		if c < 'A' || c > 'Z' {
+			return false
+		}
+	}
+	return method != ""
+}
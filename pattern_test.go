@@ -0,0 +1,127 @@
+package muxer
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestRoutePattern(t *testing.T) {
+	router := NewRouter()
+
+	var id string
+	handler := func(w http.ResponseWriter, r *http.Request) {
+		id = Params(r)["id"]
+	}
+
+	for _, pattern := range []string{"GET /x", "POST /y/:id", "/z", "* /any"} {
+		if _, err := router.Route(pattern, handler); err != nil {
+			t.Fatalf("unexpected error registering %q: %v", pattern, err)
+		}
+	}
+
+	tests := []struct {
+		method         string
+		path           string
+		expectedStatus int
+		expectedID     string
+	}{
+		{http.MethodGet, "/x", http.StatusOK, ""},
+		{http.MethodPost, "/x", http.StatusMethodNotAllowed, ""},
+		{http.MethodPost, "/y/42", http.StatusOK, "42"},
+		{http.MethodGet, "/y/42", http.StatusMethodNotAllowed, ""},
+		{http.MethodGet, "/z", http.StatusOK, ""},
+		{http.MethodDelete, "/any", http.StatusOK, ""},
+	}
+
+	for _, tc := range tests {
+		id = ""
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(tc.method, tc.path, nil))
+
+		if w.Code != tc.expectedStatus {
+			t.Errorf("unexpected status code for %s %s: expected=%d, actual=%d", tc.method, tc.path, tc.expectedStatus, w.Code)
+		}
+		if id != tc.expectedID {
+			t.Errorf("unexpected id param for %s %s: expected=%q, actual=%q", tc.method, tc.path, tc.expectedID, id)
+		}
+	}
+}
+
+func TestRoutePatternMalformed(t *testing.T) {
+	router := NewRouter()
+
+	for _, pattern := range []string{"", "GET", "get /x", "GET x", "GET /x extra"} {
+		route, err := router.Route(pattern, func(w http.ResponseWriter, r *http.Request) {})
+		if err == nil {
+			t.Errorf("expected error for pattern %q", pattern)
+		}
+		if route != nil {
+			t.Errorf("expected no route for pattern %q", pattern)
+		}
+	}
+
+	if len(router.routes) != 0 {
+		t.Errorf("unexpected routes registered: expected=0, actual=%d", len(router.routes))
+	}
+}
+
+func TestHandleStd(t *testing.T) {
+	router := NewRouter()
+
+	var params map[string]string
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		params = Params(r)
+	})
+
+	// Registrations carried over from a net/http.ServeMux.
+	for _, pattern := range []string{"GET /users/{id}", "GET /orgs/{org}/repos/{repo}", "GET /files/{rest...}", "POST /{$}", "/hooks/{name}"} {
+		if _, err := router.HandleStd(pattern, handler); err != nil {
+			t.Fatalf("unexpected error registering %q: %v", pattern, err)
+		}
+	}
+
+	tests := []struct {
+		method         string
+		path           string
+		expectedStatus int
+		expectedParams map[string]string
+	}{
+		{http.MethodGet, "/users/42", http.StatusOK, map[string]string{"id": "42"}},
+		{http.MethodGet, "/orgs/acme/repos/muxer", http.StatusOK, map[string]string{"org": "acme", "repo": "muxer"}},
+		{http.MethodGet, "/files/a/b/c.txt", http.StatusOK, map[string]string{"rest": "a/b/c.txt"}},
+		{http.MethodPost, "/", http.StatusOK, map[string]string{}},
+		{http.MethodHead, "/users/42", http.StatusOK, map[string]string{"id": "42"}},
+		{http.MethodPost, "/hooks/deploy", http.StatusOK, map[string]string{"name": "deploy"}},
+		{http.MethodGet, "/hooks/deploy", http.StatusOK, map[string]string{"name": "deploy"}},
+		{http.MethodPost, "/users/42", http.StatusMethodNotAllowed, map[string]string{}},
+	}
+
+	for _, tc := range tests {
+		params = nil
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(tc.method, tc.path, nil))
+
+		if w.Code != tc.expectedStatus {
+			t.Errorf("unexpected status code for %s %s: expected=%d, actual=%d", tc.method, tc.path, tc.expectedStatus, w.Code)
+		}
+		if len(params) != len(tc.expectedParams) {
+			t.Errorf("unexpected params for %s %s: expected=%v, actual=%v", tc.method, tc.path, tc.expectedParams, params)
+		}
+		for k, v := range tc.expectedParams {
+			if params[k] != v {
+				t.Errorf("unexpected param %q for %s %s: expected=%q, actual=%q", k, tc.method, tc.path, v, params[k])
+			}
+		}
+	}
+}
+
+func TestHandleStdMalformed(t *testing.T) {
+	router := NewRouter()
+
+	for _, pattern := range []string{"GET /users/{id", "GET /users/{}", "GET /files/{rest...}/info", "GET users"} {
+		if _, err := router.HandleStd(pattern, http.NotFoundHandler()); err == nil {
+			t.Errorf("expected error for pattern %q", pattern)
+		}
+	}
+}
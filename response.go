@@ -0,0 +1,79 @@
+package muxer
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+/*
+NoContent writes a 204 No Content response. Any Content-Type, Content-Length or
+Content-Encoding header set earlier is removed, as the response has no body. The
+handler should not write to w afterwards.
+
+	router.HandleRoute(http.MethodDelete, "/users/:id", func(w http.ResponseWriter, r *http.Request) {
+	    deleteUser(muxer.Params(r)["id"])
+	    muxer.NoContent(w)
+	})
+*/
+func NoContent(w http.ResponseWriter) {
+	header := w.Header()
+	header.Del("Content-Type")
+	header.Del("Content-Length")
+	header.Del("Content-Encoding")
+	w.WriteHeader(http.StatusNoContent)
+}
+
+/*
+String registers a route serving the fixed body as text/plain, for tiny responses
+such as a version string. See Bytes.
+
+	router.String(http.MethodGet, "/version", "1.4.2")
+*/
+func (r *Router) String(method, path, body string) *Route {
+	return r.Bytes(method, path, []byte(body), "text/plain; charset=utf-8")
+}
+
+/*
+Bytes registers a route serving the fixed body with the given content type and its
+Content-Length. An ETag is computed once at registration, so requests whose
+If-None-Match header matches it are answered with 304 Not Modified. The body must
+not be modified afterwards.
+
+	router.Bytes(http.MethodGet, "/config.json", configJSON, "application/json")
+*/
+func (r *Router) Bytes(method, path string, body []byte, contentType string) *Route {
+	sum := sha256.Sum256(body)
+	etag := `"` + hex.EncodeToString(sum[:16]) + `"`
+	length := strconv.Itoa(len(body))
+
+	return r.HandleRoute(method, path, func(w http.ResponseWriter, req *http.Request) {
+		header := w.Header()
+		header.Set("ETag", etag)
+		if etagMatches(req.Header.Get("If-None-Match"), etag) {
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+
+		header.Set("Content-Type", contentType)
+		header.Set("Content-Length", length)
+		w.Write(body) // nolint: errcheck
+	})
+}
+
+// etagMatches reports whether the If-None-Match header value matches etag, using
+// the weak comparison required for If-None-Match.
+func etagMatches(ifNoneMatch, etag string) bool {
+	if ifNoneMatch == "" {
+		return false
+	}
+	for _, candidate := range strings.Split(ifNoneMatch, ",") {
+		candidate = strings.TrimSpace(candidate)
+		if candidate == "*" || strings.TrimPrefix(candidate, "W/") == etag {
+			return true
+		}
+	}
+	return false
+}
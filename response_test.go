@@ -0,0 +1,101 @@
+package muxer
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/shellfu/muxer/middleware"
+)
+
+func TestNoContent(t *testing.T) {
+	handler := func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		NoContent(w)
+	}
+
+	tests := []struct {
+		name       string
+		middleware []func(http.Handler) http.Handler
+	}{
+		{"plain", nil},
+		{"gzip", []func(http.Handler) http.Handler{middleware.Gzip}},
+		{"compress", []func(http.Handler) http.Handler{middleware.Compress()}},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			router := NewRouter()
+			router.Use(tc.middleware...)
+			router.HandleRoute(http.MethodDelete, "/users/:id", handler)
+
+			req := httptest.NewRequest(http.MethodDelete, "/users/1", nil)
+			req.Header.Set("Accept-Encoding", "gzip")
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			if w.Code != http.StatusNoContent {
+				t.Errorf("unexpected status code: expected=%d, actual=%d", http.StatusNoContent, w.Code)
+			}
+			if w.Body.Len() != 0 {
+				t.Errorf("unexpected response body: %q", w.Body.String())
+			}
+			for _, header := range []string{"Content-Encoding", "Content-Length", "Content-Type"} {
+				if value := w.Header().Get(header); value != "" {
+					t.Errorf("unexpected %s header: %q", header, value)
+				}
+			}
+		})
+	}
+}
+
+func TestRouterStringAndBytes(t *testing.T) {
+	router := NewRouter()
+	router.String(http.MethodGet, "/version", "1.4.2")
+	router.Bytes(http.MethodGet, "/config.json", []byte(`{"debug":false}`), "application/json")
+
+	tests := []struct {
+		path                string
+		expectedBody        string
+		expectedContentType string
+		expectedLength      string
+	}{
+		{"/version", "1.4.2", "text/plain; charset=utf-8", "5"},
+		{"/config.json", `{"debug":false}`, "application/json", "15"},
+	}
+
+	for _, tc := range tests {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, tc.path, nil))
+
+		if w.Code != http.StatusOK {
+			t.Errorf("unexpected status code for %s: expected=%d, actual=%d", tc.path, http.StatusOK, w.Code)
+		}
+		if w.Body.String() != tc.expectedBody {
+			t.Errorf("unexpected response body for %s: expected=%q, actual=%q", tc.path, tc.expectedBody, w.Body.String())
+		}
+		if got := w.Header().Get("Content-Type"); got != tc.expectedContentType {
+			t.Errorf("unexpected Content-Type for %s: expected=%q, actual=%q", tc.path, tc.expectedContentType, got)
+		}
+		if got := w.Header().Get("Content-Length"); got != tc.expectedLength {
+			t.Errorf("unexpected Content-Length for %s: expected=%q, actual=%q", tc.path, tc.expectedLength, got)
+		}
+
+		etag := w.Header().Get("ETag")
+		if etag == "" {
+			t.Fatalf("expected ETag for %s", tc.path)
+		}
+
+		req := httptest.NewRequest(http.MethodGet, tc.path, nil)
+		req.Header.Set("If-None-Match", `"stale", `+etag)
+		w = httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		if w.Code != http.StatusNotModified {
+			t.Errorf("unexpected status code for conditional %s: expected=%d, actual=%d", tc.path, http.StatusNotModified, w.Code)
+		}
+		if w.Body.Len() != 0 {
+			t.Errorf("unexpected response body for conditional %s: %q", tc.path, w.Body.String())
+		}
+	}
+}
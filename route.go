@@ -1,22 +1,125 @@
 package muxer
 
 import (
+	"bytes"
 	"errors"
+	"io"
+	"mime"
+	"net"
 	"net/http"
 	"regexp"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/shellfu/muxer/middleware"
 )
 
 /*
 Route defines a mapping between an HTTP request path and an HTTP request handler.
 It contains the regular expression that matches the request path, the HTTP method,
 the handler to be executed for that request, and the parameter names extracted from the path.
+Route-local middleware is applied inside the router's global middleware, closest to the handler.
 */
 type Route struct {
-	path     *regexp.Regexp
-	method   string
-	handler  http.Handler
-	params   []string
-	template string
+	// hits is accessed atomically and kept first for 64-bit alignment on 32-bit platforms
+	hits int64
+
+	path       *regexp.Regexp
+	method     string
+	handler    http.Handler
+	params     []string
+	template   string
+	middleware []func(http.Handler) http.Handler
+	// middlewareNames are the names of middleware, reported by DescribeChain
+	middlewareNames []string
+
+	// excludedMethods are the methods a MethodAny route declines to match
+	excludedMethods map[string]bool
+
+	// host restricts the route to requests for this host, if set
+	host string
+
+	// guards are the predicates registered with Guard, which must all accept a request
+	guards []func(*http.Request) bool
+
+	// name is the name given with Name, if any
+	name string
+
+	// seq is the registration order of the route on its router
+	seq uint64
+	// node is the tree node the route is indexed at; exact reports whether the tree
+	// matches the route alone, with paramSegments the indexes of its parameter
+	// segments
+	node          *routeNode
+	exact         bool
+	paramSegments []int
+
+	// contentHandlers are the handlers registered with On, keyed by media type
+	contentHandlers map[string]http.Handler
+	// producers are the handlers registered with Produces, in registration order
+	producers []producer
+
+	// router is the router the route is registered on
+	router *Router
+	// aliasOf is the primary route of an alias registered with Alias; hasAliases
+	// marks a primary route
+	aliasOf    *Route
+	hasAliases bool
+}
+
+// matchesMethod reports whether the route accepts requests with the given method.
+func (r *Route) matchesMethod(method string) bool {
+	if r.method == MethodAny {
+		return !r.excludedMethods[method]
+	}
+	return r.method == method
+}
+
+// primary returns the route an alias was registered for, or the route itself.
+func (r *Route) primary() *Route {
+	if r.aliasOf != nil {
+		return r.aliasOf
+	}
+	return r
+}
+
+// group returns the template of the primary route for routes with aliases, which
+// marks aliases of one another in Routes, and an empty string otherwise.
+func (r *Route) group() string {
+	if r.aliasOf != nil || r.hasAliases {
+		return r.primary().template
+	}
+	return ""
+}
+
+// matchesHost reports whether the route accepts requests for the given host. A host
+// constraint of the form "*.example.com" matches any subdomain of example.com.
+func (r *Route) matchesHost(host string) bool {
+	routeHost := r.primary().host
+	if routeHost == "" {
+		return true
+	}
+	if h, _, err := net.SplitHostPort(host); err == nil {
+		host = h
+	}
+	if strings.HasPrefix(routeHost, "*.") {
+		suffix := routeHost[1:]
+		return len(host) > len(suffix) && strings.EqualFold(host[len(host)-len(suffix):], suffix)
+	}
+	return strings.EqualFold(host, routeHost)
+}
+
+// guardsAllow reports whether all guards of the route accept req. Aliases are
+// guarded by their primary route.
+func (r *Route) guardsAllow(req *http.Request) bool {
+	for _, guard := range r.primary().guards {
+		if !guard(req) {
+			return false
+		}
+	}
+	return true
 }
 
 func (r *Route) match(path string) map[string]string {
@@ -33,6 +136,12 @@ func (r *Route) match(path string) map[string]string {
 	return params
 }
 
+// Hits returns the number of requests this route has matched. Counters are cheap
+// atomic increments, useful for spotting dead routes without a metrics library.
+func (r *Route) Hits() int64 {
+	return atomic.LoadInt64(&r.hits)
+}
+
 // PathTemplate retrieves the path template of the current route
 func (r *Route) PathTemplate() (string, error) {
 	if r == nil {
@@ -45,3 +154,427 @@ func (r *Route) PathTemplate() (string, error) {
 
 	return r.template, nil
 }
+
+// Params returns the names of the route's path parameters in declaration order, such
+// as ["id", "slug"] for "/users/:id/posts/:slug". A wildcard route has the single
+// parameter "path".
+func (r *Route) Params() []string {
+	return append([]string{}, r.params...)
+}
+
+/*
+Name gives the route a name, so its URL can be built with Router.URL. Naming
+another route of the same router with the same name replaces it.
+
+	router.HandleRoute(http.MethodGet, "/users/:id", showUser).Name("user")
+	router.URL("user", map[string]string{"id": "42"}) // "/users/42"
+*/
+func (r *Route) Name(name string) *Route {
+	r.router.mu.Lock()
+	defer r.router.mu.Unlock()
+
+	if r.router.namedRoutes == nil {
+		r.router.namedRoutes = make(map[string]*Route)
+	}
+	r.name = name
+	r.router.namedRoutes[name] = r
+	return r
+}
+
+/*
+OnMethodNotAllowed sets the handler answering requests whose path matches the
+route's path template but whose method matches none of the routes registered for
+it, instead of the router's MethodNotAllowedHandler. It applies to the path, so
+it may be set on any of the routes sharing the template. The Allow header is set
+before it runs.
+
+	router.HandleRoute(http.MethodGet, "/reports/:id", showReport).
+	    OnMethodNotAllowed(func(w http.ResponseWriter, r *http.Request) {
+	        http.Error(w, "reports are read-only, allowed: "+w.Header().Get("Allow"), http.StatusMethodNotAllowed)
+	    })
+*/
+func (r *Route) OnMethodNotAllowed(handler http.HandlerFunc) *Route {
+	r.router.mu.Lock()
+	defer r.router.mu.Unlock()
+
+	if r.router.methodNotAllowedHandlers == nil {
+		r.router.methodNotAllowedHandlers = make(map[string]http.HandlerFunc)
+	}
+	r.router.methodNotAllowedHandlers[r.template] = handler
+	return r
+}
+
+/*
+Guard adds a predicate that must accept a request, after its path and method have
+matched, for the route to match. If it returns false, the router treats the route
+as not matching and continues with the routes registered after it, answering 404
+Not Found if none matches. Guards run while the route is being matched, possibly
+for routes that end up not being chosen, so they should be cheap and free of side
+effects, and must not register routes.
+
+	router.HandleRoute(http.MethodGet, "/reports", reportsV2).Guard(func(r *http.Request) bool {
+	    return r.Header.Get("X-Api-Version") == "2"
+	})
+	router.HandleRoute(http.MethodGet, "/reports", reportsV1)
+*/
+func (r *Route) Guard(fn func(*http.Request) bool) *Route {
+	r.router.mu.Lock()
+	defer r.router.mu.Unlock()
+
+	r.guards = append(r.guards, fn)
+	return r
+}
+
+/*
+Use registers middleware for this route only. Route middleware runs inside the
+router's global middleware, closest to the handler, in the given order.
+
+	router.HandleRoute(http.MethodDelete, "/users/:id", deleteUser).Use(requireAdmin, audit)
+*/
+func (r *Route) Use(mw ...func(http.Handler) http.Handler) *Route {
+	r.router.mu.Lock()
+	defer r.router.mu.Unlock()
+
+	for _, m := range mw {
+		r.addMiddleware(middlewareName(m), m)
+	}
+	return r
+}
+
+/*
+Compress enables gzip compression for this route's responses only, for clients
+that accept it. It is an alternative to registering the Gzip middleware globally
+when only a few large-payload routes benefit from compression.
+
+	router.HandleRoute(http.MethodGet, "/reports/:id", reportHandler).Compress()
+*/
+func (r *Route) Compress() *Route {
+	r.router.mu.Lock()
+	defer r.router.mu.Unlock()
+
+	r.addMiddleware(middlewareName(middleware.Gzip), middleware.Gzip)
+	return r
+}
+
+/*
+Alias registers the given paths as aliases of the route on its router. An alias
+matches requests for the same method and runs the same handler and route-local
+middleware, including middleware and host constraints configured on the route after
+Alias is called, so the paths behave identically. Routes reports the route and its
+aliases with the route's template as their Group.
+
+	router.HandleRoute(http.MethodGet, "/login", loginPage).Alias("/signin").Compress()
+*/
+func (r *Route) Alias(paths ...string) *Route {
+	primary := r.primary()
+	for _, path := range paths {
+		alias := primary.router.HandleRoute(primary.method, path, nil)
+
+		primary.router.mu.Lock()
+		alias.aliasOf = primary
+		alias.excludedMethods = primary.excludedMethods
+		primary.hasAliases = true
+		primary.router.mu.Unlock()
+	}
+	return r
+}
+
+/*
+Host restricts the route to requests whose Host matches host, ignoring any port.
+A leading wildcard label, as in "*.example.com", matches any subdomain. Requests for
+other hosts fall through to the remaining routes as if this route did not exist,
+which allows a few host-specific routes to be mixed into one router without a
+subrouter:
+
+	router.HandleRoute(http.MethodGet, "/", adminIndex).Host("admin.example.com")
+	router.HandleRoute(http.MethodGet, "/", index)
+*/
+func (r *Route) Host(host string) *Route {
+	r.router.mu.Lock()
+	defer r.router.mu.Unlock()
+
+	r.host = host
+	return r
+}
+
+/*
+On registers a handler for requests to the route whose Content-Type has the given
+media type, such as "application/json" or "multipart/form-data". A media range like
+"multipart/*" matches all subtypes; an exact media type takes precedence. Parameters
+such as charset or boundary are ignored. Requests with other content types are served
+by the route's own handler, or rejected with 415 Unsupported Media Type when it is nil:
+
+	router.HandleRoute(http.MethodPost, "/uploads", nil).
+	    On("application/json", createFromJSON).
+	    On("multipart/form-data", createFromForm)
+*/
+func (r *Route) On(contentType string, handler http.HandlerFunc) *Route {
+	r.router.mu.Lock()
+	defer r.router.mu.Unlock()
+
+	if r.contentHandlers == nil {
+		r.contentHandlers = make(map[string]http.Handler)
+
+		base := r.handler
+		if h, ok := base.(http.HandlerFunc); ok && h == nil {
+			base = nil
+		}
+		r.handler = http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			if h := r.contentHandler(req); h != nil {
+				h.ServeHTTP(w, req)
+				return
+			}
+			if base == nil {
+				http.Error(w, "Unsupported Media Type", http.StatusUnsupportedMediaType)
+				return
+			}
+			base.ServeHTTP(w, req)
+		})
+	}
+
+	r.contentHandlers[strings.ToLower(contentType)] = handler
+	return r
+}
+
+// contentHandler returns the handler registered with On for the request's content
+// type, or nil if there is none.
+func (r *Route) contentHandler(req *http.Request) http.Handler {
+	mediaType, _, err := mime.ParseMediaType(req.Header.Get("Content-Type"))
+	if err != nil {
+		return nil
+	}
+
+	r.router.mu.RLock()
+	defer r.router.mu.RUnlock()
+
+	if h, ok := r.contentHandlers[mediaType]; ok {
+		return h
+	}
+	if slash := strings.IndexByte(mediaType, '/'); slash >= 0 {
+		return r.contentHandlers[mediaType[:slash]+"/*"]
+	}
+	return nil
+}
+
+// producer is a handler registered with Produces for a media type.
+type producer struct {
+	mediaType string
+	handler   http.Handler
+}
+
+/*
+Produces registers a handler producing the given media type, such as
+"application/json". Once a route has producers, requests are dispatched to the one
+the Accept header prefers: the highest quality value wins, ties go to the producer
+registered first, and a request without an Accept header gets the first producer.
+Requests accepting none of the media types are rejected with 406 Not Acceptable.
+The route's own handler is no longer used.
+
+	router.HandleRoute(http.MethodGet, "/users/:id", nil).
+	    Produces("application/json", userJSON).
+	    Produces("application/xml", userXML)
+*/
+func (r *Route) Produces(mediaType string, handler http.HandlerFunc) *Route {
+	r.router.mu.Lock()
+	defer r.router.mu.Unlock()
+
+	if r.producers == nil {
+		r.handler = http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			w.Header().Add("Vary", "Accept")
+			h := r.producer(req)
+			if h == nil {
+				http.Error(w, "Not Acceptable", http.StatusNotAcceptable)
+				return
+			}
+			h.ServeHTTP(w, req)
+		})
+	}
+
+	r.producers = append(r.producers, producer{mediaType: strings.ToLower(mediaType), handler: handler})
+	return r
+}
+
+// producer returns the handler registered with Produces that the request's Accept
+// header prefers, or nil if it accepts none of them.
+func (r *Route) producer(req *http.Request) http.Handler {
+	r.router.mu.RLock()
+	defer r.router.mu.RUnlock()
+
+	accept := req.Header.Values("Accept")
+	if len(accept) == 0 {
+		return r.producers[0].handler
+	}
+
+	var ranges []acceptRange
+	for _, value := range accept {
+		for _, part := range strings.Split(value, ",") {
+			if ar, ok := parseAcceptRange(part); ok {
+				ranges = append(ranges, ar)
+			}
+		}
+	}
+
+	var best http.Handler
+	bestQuality := 0.0
+	for _, p := range r.producers {
+		quality, specificity := 0.0, -1
+		for _, ar := range ranges {
+			if s := ar.matches(p.mediaType); s > specificity {
+				quality, specificity = ar.quality, s
+			}
+		}
+		if specificity >= 0 && quality > bestQuality {
+			best, bestQuality = p.handler, quality
+		}
+	}
+	return best
+}
+
+// acceptRange is a media range of an Accept header with its quality value.
+type acceptRange struct {
+	mediaType string
+	quality   float64
+}
+
+// parseAcceptRange parses a single media range of an Accept header.
+func parseAcceptRange(part string) (acceptRange, bool) {
+	mediaType, params, err := mime.ParseMediaType(strings.TrimSpace(part))
+	if err != nil {
+		return acceptRange{}, false
+	}
+
+	ar := acceptRange{mediaType: mediaType, quality: 1}
+	if q, ok := params["q"]; ok {
+		if quality, err := strconv.ParseFloat(q, 64); err == nil && quality >= 0 && quality <= 1 {
+			ar.quality = quality
+		}
+	}
+	return ar, true
+}
+
+// matches returns how specifically the range matches mediaType: 2 for an exact
+// match, 1 for a type/* range and 0 for */*. It returns -1 if it does not match.
+func (ar acceptRange) matches(mediaType string) int {
+	switch {
+	case ar.mediaType == mediaType:
+		return 2
+	case ar.mediaType == "*/*":
+		return 0
+	case strings.HasSuffix(ar.mediaType, "/*") && strings.HasPrefix(mediaType, ar.mediaType[:len(ar.mediaType)-1]):
+		return 1
+	}
+	return -1
+}
+
+/*
+ValidateBody registers a validator that runs against the request body before the
+route's handler. The body is buffered (subject to the router's MaxRequestBodySize),
+passed to fn and then restored so the handler can read it again. If fn returns an
+error, the request is rejected with 422 Unprocessable Entity and the error message
+as the response body.
+
+The validator is injected so any schema library can be used without muxer depending
+on it:
+
+	router.HandleRoute(http.MethodPost, "/users", createUser).ValidateBody(func(body []byte) error {
+	    return userSchema.Validate(body)
+	})
+*/
+func (r *Route) ValidateBody(fn func(body []byte) error) *Route {
+	r.router.mu.Lock()
+	defer r.router.mu.Unlock()
+
+	mw := func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			var body []byte
+			if req.Body != nil {
+				var err error
+				body, err = io.ReadAll(req.Body)
+				if err != nil {
+					if errors.Is(err, errBodyTooLarge) {
+						http.Error(w, "Request body too large", http.StatusRequestEntityTooLarge)
+						return
+					}
+					http.Error(w, "Failed to read request body", http.StatusBadRequest)
+					return
+				}
+				req.Body = io.NopCloser(bytes.NewReader(body))
+			}
+
+			if err := fn(body); err != nil {
+				http.Error(w, err.Error(), http.StatusUnprocessableEntity)
+				return
+			}
+
+			next.ServeHTTP(w, req)
+		})
+	}
+	r.addMiddleware(middlewareName(mw), mw)
+	return r
+}
+
+/*
+MaxConcurrent caps the number of requests this route handles concurrently at n.
+Requests arriving while n requests are in flight are rejected immediately with
+503 Service Unavailable. A non-positive n leaves the route unlimited.
+
+	router.HandleRoute(http.MethodGet, "/reports", reportHandler).MaxConcurrent(10)
+*/
+func (r *Route) MaxConcurrent(n int) *Route {
+	return r.MaxConcurrentWait(n, 0)
+}
+
+/*
+MaxConcurrentWait is like MaxConcurrent, but a request arriving while the route is
+full waits up to wait for a slot to free up before being rejected with 503.
+*/
+func (r *Route) MaxConcurrentWait(n int, wait time.Duration) *Route {
+	if n <= 0 {
+		return r
+	}
+
+	r.router.mu.Lock()
+	defer r.router.mu.Unlock()
+
+	sem := make(chan struct{}, n)
+	mw := func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			if !acquire(req, sem, wait) {
+				r.router.writeError(w, HTTPError{Status: http.StatusServiceUnavailable})
+				return
+			}
+			// Released in a defer so a panicking handler doesn't leak its slot
+			defer func() { <-sem }()
+
+			next.ServeHTTP(w, req)
+		})
+	}
+	r.addMiddleware(middlewareName(mw), mw)
+	return r
+}
+
+// acquire takes a slot in sem, waiting up to wait for one to become available.
+// It gives up early if the request context is done.
+func acquire(req *http.Request, sem chan struct{}, wait time.Duration) bool {
+	select {
+	case sem <- struct{}{}:
+		return true
+	default:
+	}
+
+	if wait <= 0 {
+		return false
+	}
+
+	timer := time.NewTimer(wait)
+	defer timer.Stop()
+
+	select {
+	case sem <- struct{}{}:
+		return true
+	case <-timer.C:
+		return false
+	case <-req.Context().Done():
+		return false
+	}
+}
@@ -2,9 +2,18 @@ package muxer
 
 import (
 	"context"
+	"log"
 	"net/http"
+	"net/url"
 	"regexp"
+	"runtime/debug"
+	"sort"
 	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/shellfu/muxer/middleware"
 )
 
 type contextKey string
@@ -16,27 +25,125 @@ const (
 	RouteContextKey contextKey = "matched_route"
 )
 
+// matchedPrefixKey is the context key under which the prefix of the handling subrouter is stored.
+const matchedPrefixKey contextKey = "matched_prefix"
+
+// strippedPathKey is the context key under which the part of the request path
+// stripped by subrouters is stored.
+const strippedPathKey contextKey = "stripped_path"
+
+// MethodAny is the method of routes registered with HandleAny or HandleExcept,
+// which match requests of any HTTP method.
+const MethodAny = "*"
+
+// standardMethods are the methods listed in the Allow header for a MethodAny
+// route, less those it excludes.
+var standardMethods = []string{
+	http.MethodGet, http.MethodHead, http.MethodPost, http.MethodPut, http.MethodPatch,
+	http.MethodDelete, http.MethodConnect, http.MethodOptions, http.MethodTrace,
+}
+
+// greedyModifier marks a path parameter that may contain slashes, e.g. "/proxy/:target{greedy}/info".
+const greedyModifier = "{greedy}"
+
+// templateParamRegex matches the parameters of a route template.
+var templateParamRegex = regexp.MustCompile(`:([\w-]+)(\{greedy\})?`)
+
 /*
 Router is an HTTP request multiplexer. It contains the registered routes and middleware functions.
 It implements the http.Handler interface to be used with the http.ListenAndServe function.
 */
 type Router struct {
+	// active is accessed atomically and kept first for 64-bit alignment on 32-bit platforms
+	active int64
+
 	http.Handler
 
-	routes     []Route
+	// mu guards routes, middleware and subrouters so routes can be registered and
+	// unregistered while the router is serving requests.
+	mu         sync.RWMutex
+	routes     []*Route
 	middleware []func(http.Handler) http.Handler
-	subrouters map[string]*Router
+	// middlewareNames are the names of middleware, reported by DescribeChain
+	middlewareNames []string
+	subrouters      map[string]*Router
+
+	// staticRoutes indexes routes without parameters or wildcards by method and path,
+	// so they are found without scanning routes.
+	staticRoutes map[string]*Route
+
+	// tree indexes all routes by path segment; seq numbers them in registration order.
+	tree *routeNode
+	seq  uint64
+
+	// namedRoutes holds the routes given a name with Route.Name, for URL.
+	namedRoutes map[string]*Route
+
+	// methodNotAllowedHandlers holds the handlers set with Route.OnMethodNotAllowed,
+	// keyed by path template.
+	methodNotAllowedHandlers map[string]http.HandlerFunc
+
+	// subrouterPatterns holds the compiled patterns of subrouter attribute values
+	// containing parameters, keyed like subrouters.
+	subrouterPatterns map[string]*subrouterPattern
+
+	// headLiveness enables answering HEAD requests with 200 before routing. When
+	// headLivenessPaths is empty, every path is a liveness path.
+	headLiveness      bool
+	headLivenessPaths map[string]bool
 
-	NotFoundHandler    http.HandlerFunc
-	MaxRequestBodySize int64
+	// errorContentType is the content type errors are rendered in by HandleErr.
+	errorContentType string
+
+	// recovery wraps request dispatch to recover from panics, if set.
+	recovery func(http.Handler) http.Handler
+
+	// autoOptions answers OPTIONS requests to known paths with 204 and an Allow header.
+	autoOptions bool
+	// autoHead serves HEAD requests with the GET route of a path when it has no HEAD route.
+	autoHead bool
+	// caseInsensitive matches the literal parts of route paths regardless of case.
+	caseInsensitive bool
+
+	// routeContextDecorator derives the request context for a matched route, if set.
+	routeContextDecorator func(ctx context.Context, route *Route) context.Context
+
+	// metrics records matched requests once enabled by MetricsHandler.
+	metrics *metricsRegistry
+
+	// maxPathSegments is the number of path segments above which requests are
+	// rejected before matching, if positive.
+	maxPathSegments int
+
+	// retryAfter is sent as Retry-After on 503 responses generated by the router, if positive.
+	retryAfter time.Duration
+
+	// rejectUnknownLength rejects request bodies without a declared length with 411.
+	rejectUnknownLength bool
+
+	// wildcardQuery appends the query string to the "path" parameter of wildcard routes.
+	wildcardQuery bool
+
+	// discovery answers unmatched requests under a prefix, see WithDiscovery.
+	discovery *discovery
+
+	NotFoundHandler http.HandlerFunc
+	// PanicHandler handles a panic during dispatch, with the panicked value as err.
+	// When nil, a top-level router without WithRecovery answers panics with 500.
+	PanicHandler func(w http.ResponseWriter, r *http.Request, err interface{})
+	// MethodNotAllowedHandler handles requests whose path matches a route registered
+	// for other methods only. The Allow header is set before it runs.
+	MethodNotAllowedHandler http.HandlerFunc
+	MaxRequestBodySize      int64
 }
 
 // NewRouter creates a new instance of a Router with optional configuration provided
 // through the RouterOptions
 func NewRouter(options ...RouterOption) *Router {
 	r := &Router{
-		NotFoundHandler: http.HandlerFunc(http.NotFound),
-		subrouters:      make(map[string]*Router),
+		NotFoundHandler:         http.HandlerFunc(http.NotFound),
+		MethodNotAllowedHandler: methodNotAllowed,
+		subrouters:              make(map[string]*Router),
 	}
 
 	for _, option := range options {
@@ -51,20 +158,101 @@ Subrouter returns a new router that will handle requests that match the given at
 The attribute value can be, for example, a host or path prefix. If a subrouter does not already exist
 for the given attribute value, a new one will be created. The new router will inherit the parent router's
 NotFoundHandler and other settings.
+
+The attribute value may contain parameters, such as ":tenant.example.com" for a host
+or "/orgs/:org" for a path prefix. A host parameter matches a single label of the
+request's host and a path parameter a single path segment. Parameters captured by
+subrouters are merged into the route parameters, so Params returns them together with
+those of the matched route:
+
+	tenants := router.Subrouter(":tenant.example.com")
+	tenants.HandleRoute(http.MethodGet, "/users/:id", func(w http.ResponseWriter, r *http.Request) {
+	    params := muxer.Params(r) // "tenant" and "id"
+	})
 */
 func (r *Router) Subrouter(attrValue string) *Router {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
 	if _, ok := r.subrouters[attrValue]; !ok {
+		if pattern := compileSubrouterPattern(attrValue); pattern != nil {
+			if r.subrouterPatterns == nil {
+				r.subrouterPatterns = make(map[string]*subrouterPattern)
+			}
+			r.subrouterPatterns[attrValue] = pattern
+		}
+
 		// If subrouter doesn't exist for attribute value, create one
 		subrouter := &Router{
-			NotFoundHandler: r.NotFoundHandler,
-			middleware:      append([]func(http.Handler) http.Handler{}, r.middleware...),
-			subrouters:      make(map[string]*Router),
+			NotFoundHandler:         r.NotFoundHandler,
+			PanicHandler:            r.PanicHandler,
+			MethodNotAllowedHandler: r.MethodNotAllowedHandler,
+			middleware:              append([]func(http.Handler) http.Handler{}, r.middleware...),
+			middlewareNames:         append([]string{}, r.middlewareNames...),
+			subrouters:              make(map[string]*Router),
+			routeContextDecorator:   r.routeContextDecorator,
+			errorContentType:        r.errorContentType,
+			retryAfter:              r.retryAfter,
+			autoOptions:             r.autoOptions,
+			autoHead:                r.autoHead,
+			caseInsensitive:         r.caseInsensitive,
+			wildcardQuery:           r.wildcardQuery,
+			discovery:               r.discovery,
 		}
 		r.subrouters[attrValue] = subrouter
 	}
 	return r.subrouters[attrValue]
 }
 
+/*
+CloneConfig returns a new router with the configuration of r: the settings applied
+by RouterOptions, the global middleware, NotFoundHandler, PanicHandler,
+MethodNotAllowedHandler and MaxRequestBodySize. Routes and subrouters are not copied, so the clone starts
+empty and registering routes on it does not affect r. Metrics collection enabled
+with MetricsHandler is not copied either, as it belongs to the metrics route.
+
+	base := muxer.NewRouter(muxer.WithAutoOptions(), muxer.WithRecovery(logger, false))
+	base.Use(middleware.RequestID())
+
+	public := base.CloneConfig()
+	admin := base.CloneConfig()
+*/
+func (r *Router) CloneConfig() *Router {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	clone := &Router{
+		middleware:              append([]func(http.Handler) http.Handler{}, r.middleware...),
+		middlewareNames:         append([]string{}, r.middlewareNames...),
+		subrouters:              make(map[string]*Router),
+		headLiveness:            r.headLiveness,
+		errorContentType:        r.errorContentType,
+		recovery:                r.recovery,
+		autoOptions:             r.autoOptions,
+		autoHead:                r.autoHead,
+		caseInsensitive:         r.caseInsensitive,
+		routeContextDecorator:   r.routeContextDecorator,
+		maxPathSegments:         r.maxPathSegments,
+		retryAfter:              r.retryAfter,
+		rejectUnknownLength:     r.rejectUnknownLength,
+		wildcardQuery:           r.wildcardQuery,
+		NotFoundHandler:         r.NotFoundHandler,
+		PanicHandler:            r.PanicHandler,
+		MethodNotAllowedHandler: r.MethodNotAllowedHandler,
+		MaxRequestBodySize:      r.MaxRequestBodySize,
+	}
+	if r.headLivenessPaths != nil {
+		clone.headLivenessPaths = make(map[string]bool, len(r.headLivenessPaths))
+		for path := range r.headLivenessPaths {
+			clone.headLivenessPaths[path] = true
+		}
+	}
+	if r.discovery != nil {
+		clone.discovery = &discovery{prefix: r.discovery.prefix, handler: r.discovery.handler, router: clone}
+	}
+	return clone
+}
+
 /*
 Handle registers a new route with the given method, path and handler.
 
@@ -78,8 +266,8 @@ The handler parameter is the HTTP handler function that will be executed when th
 is matched. The handler function should take an http.ResponseWriter and an *http.Request
 as its parameters.
 */
-func (r *Router) Handle(method string, path string, handler http.Handler) {
-	r.HandlerFunc(method, path, func(w http.ResponseWriter, req *http.Request) {
+func (r *Router) Handle(method string, path string, handler http.Handler) *Route {
+	return r.HandlerFunc(method, path, func(w http.ResponseWriter, req *http.Request) {
 		handler.ServeHTTP(w, req)
 	})
 }
@@ -97,8 +285,8 @@ method of the Router.
 The handler function may be provided as an http.HandlerFunc, or as any other function that satisfies
 the http.Handler interface (e.g. a method of a struct that implements ServeHTTP).
 */
-func (r *Router) HandlerFunc(method, path string, handlerFunc http.HandlerFunc) {
-	r.HandleRoute(method, path, handlerFunc)
+func (r *Router) HandlerFunc(method, path string, handlerFunc http.HandlerFunc) *Route {
+	return r.HandleRoute(method, path, handlerFunc)
 }
 
 /*
@@ -110,12 +298,19 @@ The method parameter specifies the HTTP method (e.g. GET, POST, PUT, DELETE, etc
 the route should match. If an unsupported method is passed, an error will be returned.
 
 The path parameter specifies the URL path that the route should match. Path parameters
-are denoted by a colon followed by the parameter name (e.g. "/users/:id").
+are denoted by a colon followed by the parameter name (e.g. "/users/:id"). Parameters
+match a single path segment, unless marked with the {greedy} modifier, which lets them
+span slashes up to the next literal part of the path: "/proxy/:target{greedy}/info"
+matches "/proxy/a/b/c/info" with target "a/b/c". Unlike a trailing wildcard, a greedy
+parameter can be followed by more of the path.
 
 The handler parameter is the HTTP handler function that will be executed when the route
 is matched. The handler function should take an http.ResponseWriter and an *http.Request
 as its parameters.
 
+The registered Route is returned so route-local behaviour, such as Compress, can be
+configured on it.
+
 	Example usage:
 	  router := muxer.NewRouter()
 	  router.HandleRoute("GET", "/users/:id", func(w http.ResponseWriter, r *http.Request) {
@@ -127,8 +322,13 @@ as its parameters.
 	      // ...
 	  })
 */
-func (r *Router) HandleRoute(method, path string, handler http.HandlerFunc) {
-	paramNames := make([]string, 0)
+func (r *Router) HandleRoute(method, path string, handler http.HandlerFunc) *Route {
+	route := &Route{
+		method:   method,
+		handler:  handler,
+		params:   make([]string, 0),
+		template: path,
+	}
 
 	// First handle catch-all wildcard
 	if strings.Contains(path, "*") {
@@ -136,35 +336,145 @@ func (r *Router) HandleRoute(method, path string, handler http.HandlerFunc) {
 		base = strings.TrimSuffix(base, "/")
 		// Match everything after the base path, but don't capture the leading slash
 		pathRegex := regexp.QuoteMeta(base) + `/(.+)`
-		paramNames = append(paramNames, "path")
-
-		r.routes = append(r.routes, Route{
-			method:   method,
-			path:     regexp.MustCompile("^" + pathRegex + "$"),
-			handler:  handler,
-			params:   paramNames,
-			template: path,
-		})
-		return
+		route.params = append(route.params, "path")
+		route.path = regexp.MustCompile(r.regexFlags() + "^" + pathRegex + "$")
+
+		r.addRoute(route)
+		return route
 	}
 
-	// Handle standard path parameters with the original pattern
-	re := regexp.MustCompile(`:([\w-]+)`)
-	pathRegex := re.ReplaceAllStringFunc(path, func(m string) string {
-		paramName := m[1:]
-		paramNames = append(paramNames, paramName)
+	// Handle standard path parameters with the original pattern. A parameter marked
+	// {greedy} may also contain slashes, up to the next literal part of the path.
+	pathRegex := templateParamRegex.ReplaceAllStringFunc(path, func(m string) string {
+		paramName := strings.TrimSuffix(m[1:], greedyModifier)
+		route.params = append(route.params, paramName)
+		if strings.HasSuffix(m, greedyModifier) {
+			return `(.+?)`
+		}
 		return `([-\w.]+)` // Maintain original pattern
 	})
 
-	exactPath := regexp.MustCompile("^" + pathRegex + "$")
+	route.path = regexp.MustCompile(r.regexFlags() + "^" + pathRegex + "$")
 
-	r.routes = append(r.routes, Route{
-		method:   method,
-		path:     exactPath,
-		handler:  handler,
-		params:   paramNames,
-		template: path,
-	})
+	r.addRoute(route)
+	return route
+}
+
+// regexFlags returns the flags route path regular expressions are compiled with.
+func (r *Router) regexFlags() string {
+	if r.caseInsensitive {
+		return "(?i)"
+	}
+	return ""
+}
+
+// addRoute appends route to the routing table and indexes it.
+func (r *Router) addRoute(route *Route) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.seq++
+	route.seq = r.seq
+	route.router = r
+	r.routes = append(r.routes, route)
+	r.indexStaticRoute(route)
+
+	if r.tree == nil {
+		r.tree = &routeNode{}
+	}
+	r.tree.insert(route, r.caseInsensitive)
+}
+
+// indexStaticRoute adds route to staticRoutes if it has no parameters or wildcards
+// and no earlier route is indexed for its method and path. The caller must hold r.mu.
+func (r *Router) indexStaticRoute(route *Route) {
+	if route.method == MethodAny || strings.ContainsAny(route.template, ":*") {
+		return
+	}
+	if r.staticRoutes == nil {
+		r.staticRoutes = make(map[string]*Route)
+	}
+	key := r.staticRouteKey(route.method, route.template)
+	if _, ok := r.staticRoutes[key]; !ok {
+		r.staticRoutes[key] = route
+	}
+}
+
+// staticRouteKey returns the staticRoutes key for method and path. The path is
+// lowercased when matching is case-insensitive.
+func (r *Router) staticRouteKey(method, path string) string {
+	if r.caseInsensitive {
+		path = strings.ToLower(path)
+	}
+	return method + " " + path
+}
+
+/*
+HandleAny registers a route matching requests of any HTTP method for the given path.
+The route is stored with the MethodAny method.
+*/
+func (r *Router) HandleAny(path string, handler http.HandlerFunc) *Route {
+	return r.HandleRoute(MethodAny, path, handler)
+}
+
+/*
+HandleExcept registers a route matching requests of any HTTP method except the
+excluded ones for the given path. Requests using an excluded method fall through
+to other routes and, failing those, receive a 405 Method Not Allowed.
+
+	// Proxy everything except TRACE and CONNECT
+	router.HandleExcept("/proxy/*", []string{http.MethodTrace, http.MethodConnect}, proxyHandler)
+*/
+func (r *Router) HandleExcept(path string, exclude []string, handler http.HandlerFunc) *Route {
+	route := r.HandleAny(path, handler)
+	route.excludedMethods = make(map[string]bool, len(exclude))
+	for _, method := range exclude {
+		route.excludedMethods[method] = true
+	}
+	return route
+}
+
+/*
+Index registers handler for GET and HEAD requests to the root path "/" and returns
+the GET route. The HEAD route runs the GET route's handler and route-local
+middleware, like an alias, so configuration applied to the returned route applies
+to both.
+
+Routes match the whole request path, so the index route matches "/" only and not
+"/anything"; register a wildcard route such as "/*" to catch every other path, or
+use the NotFoundHandler.
+
+	router.Index(homePage).Compress()
+*/
+func (r *Router) Index(handler http.HandlerFunc) *Route {
+	route := r.HandleRoute(http.MethodGet, "/", handler)
+	r.handleHead(route, "/")
+	return route
+}
+
+// handleHead registers a HEAD route for path that runs the handler and route-local
+// middleware of route, like an alias.
+func (r *Router) handleHead(route *Route, path string) {
+	head := r.HandleRoute(http.MethodHead, path, nil)
+
+	r.mu.Lock()
+	head.aliasOf = route
+	route.hasAliases = true
+	r.mu.Unlock()
+}
+
+/*
+HandleMany registers handler for method on each of the given paths. The paths after
+the first are registered as aliases of the route for the first one, which is
+returned, so route-local configuration applied to it applies to all paths:
+
+	router.HandleMany(http.MethodGet, []string{"/login", "/signin"}, loginPage).Compress()
+*/
+func (r *Router) HandleMany(method string, paths []string, handler http.HandlerFunc) *Route {
+	if len(paths) == 0 {
+		return nil
+	}
+	return r.HandleRoute(method, paths[0], handler).Alias(paths[1:]...)
 }
 
 // HandlerFuncWithMethods is a convenience method for registering a new route with multiple HTTP methods.
@@ -187,64 +497,379 @@ the HTTP method and path of the request. It executes the middleware functions
 in reverse order and sets the extracted parameters in the request context.
 If there's no registered route that matches the request, it returns a
 404 HTTP status code.
+
+A panic during dispatch, including middleware and subrouters, is passed to the
+PanicHandler if it is set, which takes precedence over router-level recovery
+enabled with WithRecovery. Otherwise it is recovered by that recovery, or else by
+the top-level router, which classifies it as WithRecovery does: a panicked
+HTTPError, or a value with a StatusCode method, is answered with its status and
+message, and any other panic is logged with its stack trace and answered with 500
+Internal Server Error. http.ErrAbortHandler panics are always re-raised.
 */
 func (r *Router) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	atomic.AddInt64(&r.active, 1)
+	defer atomic.AddInt64(&r.active, -1)
+
+	// Subrouters leave panics to their parent unless they have their own handler
+	_, nested := req.Context().Value(matchedPrefixKey).(string)
+	if r.PanicHandler != nil || (r.recovery == nil && !nested) {
+		defer r.recoverPanic(w, req)
+	}
+
+	if r.recovery != nil && r.PanicHandler == nil {
+		r.recovery(http.HandlerFunc(r.serveHTTP)).ServeHTTP(w, req)
+		return
+	}
+	r.serveHTTP(w, req)
+}
+
+// recoverPanic recovers a panic and passes it to the PanicHandler. When there is
+// none, it answers a panic classified by classifyPanic with its status and message,
+// and logs any other panic and answers it with 500 Internal Server Error. It must
+// be deferred.
+func (r *Router) recoverPanic(w http.ResponseWriter, req *http.Request) {
+	err := recover()
+	if err == nil {
+		return
+	}
+	if err == http.ErrAbortHandler {
+		panic(err)
+	}
+	if r.PanicHandler != nil {
+		r.PanicHandler(w, req, err)
+		return
+	}
+	if status, message, ok := classifyPanic(err); ok {
+		r.renderError(w, HTTPError{Status: status, Message: message})
+		return
+	}
+	log.Printf("muxer: panic serving %s %s: %v\n%s", req.Method, req.URL.Path, err, debug.Stack())
+	http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+}
+
+// serveHTTP implements ServeHTTP without router-level panic recovery.
+func (r *Router) serveHTTP(w http.ResponseWriter, req *http.Request) {
+	if r.rejectUnknownLength && req.ContentLength < 0 && req.Body != nil && req.Body != http.NoBody {
+		http.Error(w, "Length Required", http.StatusLengthRequired)
+		return
+	}
+
 	if r.MaxRequestBodySize > 0 && req.Body != nil {
 		if req.ContentLength <= r.MaxRequestBodySize {
-			req.Body = http.MaxBytesReader(w, req.Body, r.MaxRequestBodySize)
+			budget := &bodyBudget{limit: r.MaxRequestBodySize}
+			req.Body = &countingBody{ReadCloser: http.MaxBytesReader(w, req.Body, r.MaxRequestBodySize), budget: budget}
+			req = req.WithContext(context.WithValue(req.Context(), bodyBudgetKey, budget))
 		} else {
 			http.Error(w, "Request body too large", http.StatusRequestEntityTooLarge)
 			return
 		}
 	}
 
+	if r.maxPathSegments > 0 && pathSegments(req.URL.Path) > r.maxPathSegments {
+		http.Error(w, "URI Too Long", http.StatusRequestURITooLong)
+		return
+	}
+
+	req = r.withMetrics(req)
+
+	if req.Method == http.MethodHead && r.isLivenessPath(req.URL.Path) {
+		w.WriteHeader(http.StatusOK)
+		return
+	}
+
 	// Check subrouters first
+	path := req.URL.Path
+	if subrouter, prefix, isHost, params := r.matchSubrouter(req); subrouter != nil {
+		if !isHost {
+			prefix = MatchedPrefix(req) + prefix
+		}
+		ctx := context.WithValue(req.Context(), matchedPrefixKey, prefix)
+		if stripped := path[:len(path)-len(req.URL.Path)]; stripped != "" {
+			stripRawPath(req.URL, stripped)
+			ctx = context.WithValue(ctx, strippedPathKey, strippedPath(req)+stripped)
+		}
+		if len(params) > 0 {
+			ctx = context.WithValue(ctx, ParamsKey, mergeParams(params, Params(req)))
+		}
+		subrouter.ServeHTTP(w, req.WithContext(ctx))
+		return
+	}
+
+	r.mu.RLock()
+	route, params, methodMismatch := r.matchRoute(req.Method, req)
+	if route == nil && req.Method == http.MethodHead && r.autoHead {
+		route, params, _ = r.matchRoute(http.MethodGet, req)
+	}
+	var allowed []string
+	var notAllowed http.HandlerFunc
+	if route == nil && methodMismatch {
+		allowed, notAllowed = r.allowedMethods(req.Host, req.URL.Path)
+	}
+	globalMiddleware := r.middleware
+	// Snapshot the route's handler and middleware, which route setters may still be
+	// changing; aliases run those of their primary route
+	var routeHandler http.Handler
+	var routeMiddleware []func(http.Handler) http.Handler
+	if route != nil {
+		primary := route.primary()
+		routeHandler, routeMiddleware = primary.handler, primary.middleware
+	}
+	r.mu.RUnlock()
+
+	if route == nil {
+		if methodMismatch {
+			w.Header().Set("Allow", strings.Join(allowed, ", "))
+			if req.Method == http.MethodOptions && r.autoOptions {
+				w.WriteHeader(http.StatusNoContent)
+				return
+			}
+			if notAllowed != nil {
+				notAllowed.ServeHTTP(w, req)
+				return
+			}
+			r.MethodNotAllowedHandler.ServeHTTP(w, req)
+			return
+		}
+
+		if r.discovery != nil && r.discovery.matches(req) {
+			r.discovery.serve(w, req)
+			return
+		}
+		r.NotFoundHandler.ServeHTTP(w, req)
+		return
+	}
+
+	atomic.AddInt64(&route.hits, 1)
+
+	if r.wildcardQuery && req.URL.RawQuery != "" && strings.Contains(route.template, "*") {
+		params["path"] += "?" + req.URL.RawQuery
+	}
+
+	ctx := req.Context()
+	ctx = context.WithValue(ctx, ParamsKey, mergeParams(params, Params(req)))
+	ctx = context.WithValue(ctx, RouteContextKey, route)
+	if r.routeContextDecorator != nil {
+		ctx = r.routeContextDecorator(ctx, route)
+	}
+	if route.method == http.MethodOptions {
+		// An explicit OPTIONS route takes precedence over the CORS preflight short-circuit
+		ctx = middleware.WithExplicitOptions(ctx)
+	}
+
+	handler := r.withErrorWriter(routeHandler)
+	for i := len(routeMiddleware) - 1; i >= 0; i-- {
+		handler = routeMiddleware[i](handler)
+	}
+	for i := len(globalMiddleware) - 1; i >= 0; i-- {
+		handler = globalMiddleware[i](handler)
+	}
+	if registry, ok := req.Context().Value(metricsKey).(*metricsRegistry); ok {
+		handler = registry.instrument(handler, MatchedPrefix(req)+route.template)
+	}
+
+	if req.Method == http.MethodHead {
+		// HEAD responses carry no body, whether the route is explicit or synthesized
+		w = &headResponseWriter{ResponseWriter: w}
+	}
+
+	handler.ServeHTTP(w, req.WithContext(ctx))
+}
+
+// methodNotAllowed is the default MethodNotAllowedHandler.
+func methodNotAllowed(w http.ResponseWriter, req *http.Request) {
+	http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+}
+
+// pathSegments returns the number of segments of path, ignoring leading and trailing slashes.
+func pathSegments(path string) int {
+	path = strings.Trim(path, "/")
+	if path == "" {
+		return 0
+	}
+	return strings.Count(path, "/") + 1
+}
+
+// isLivenessPath reports whether HEAD requests to path are answered as liveness probes.
+func (r *Router) isLivenessPath(path string) bool {
+	return r.headLiveness && (len(r.headLivenessPaths) == 0 || r.headLivenessPaths[path])
+}
+
+// matchSubrouter returns the subrouter responsible for the request, if any, together
+// with the host or path prefix it is registered for and the parameters captured by
+// it. A matched path prefix is trimmed from the request path.
+func (r *Router) matchSubrouter(req *http.Request) (subrouter *Router, prefix string, isHost bool, params map[string]string) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
 	for prefix, subrouter := range r.subrouters {
-		var matched bool
+		if pattern := r.subrouterPatterns[prefix]; pattern != nil {
+			if params, ok := pattern.match(req); ok {
+				return subrouter, prefix, pattern.isHost, params
+			}
+			continue
+		}
+
 		switch {
 		case prefix == req.URL.Host:
-			matched = true
+			return subrouter, prefix, true, nil
 		case strings.HasPrefix(req.URL.Path, prefix):
-			matched = true
 			req.URL.Path = strings.TrimPrefix(req.URL.Path, prefix)
+			return subrouter, prefix, false, nil
 		}
+	}
+	return nil, "", false, nil
+}
 
-		if matched {
-			subrouter.ServeHTTP(w, req)
-			return
+// stripRawPath removes the escaped form of prefix, which a subrouter stripped from
+// the decoded Path, from the start of RawPath, keeping the two in step so escapes
+// such as %2F survive in EscapedPath.
+func stripRawPath(u *url.URL, prefix string) {
+	if u.RawPath == "" {
+		return
+	}
+	i := 0
+	for n := 0; n < len(prefix) && i < len(u.RawPath); n++ {
+		if u.RawPath[i] == '%' {
+			i += 3
+		} else {
+			i++
 		}
 	}
+	if i > len(u.RawPath) {
+		u.RawPath = ""
+		return
+	}
+	u.RawPath = u.RawPath[i:]
+}
+
+// matchRoute returns the first registered route matching method and the host and
+// path of req, and whose guards accept req, together with the extracted parameters.
+// If no route matches, methodMismatch reports whether a route registered for another
+// method matches the host and path. Static routes are looked up first, so they take
+// precedence over parameterized routes registered before them. The caller must hold
+// r.mu.
+func (r *Router) matchRoute(method string, req *http.Request) (route *Route, params map[string]string, methodMismatch bool) {
+	host, path := req.Host, req.URL.Path
+	if route := r.staticRoutes[r.staticRouteKey(method, path)]; route != nil && route.matchesHost(host) && route.guardsAllow(req) {
+		return route, make(map[string]string), false
+	}
+
+	if r.tree == nil {
+		return nil, nil, false
+	}
 
-	var methodMismatch bool
-	for _, route := range r.routes {
-		if route.method != req.Method {
+	// Of the routes matching the path, the one registered first wins
+	var best *Route
+	r.tree.visit(path, false, r.caseInsensitive, func(candidate *Route, exact bool) {
+		if best != nil && candidate.seq > best.seq {
+			return
+		}
+		if !candidate.matchesHost(host) || (!exact && !candidate.path.MatchString(path)) {
+			return
+		}
+		if !candidate.matchesMethod(method) {
 			methodMismatch = true
-			continue
+			return
 		}
-		params := route.match(req.URL.Path)
-		if params == nil {
-			continue
+		if !candidate.guardsAllow(req) {
+			return
 		}
+		best = candidate
+	})
 
-		ctx := req.Context()
-		ctx = context.WithValue(ctx, ParamsKey, params)
-		ctx = context.WithValue(ctx, RouteContextKey, &route)
-
-		handler := route.handler
-		for i := len(r.middleware) - 1; i >= 0; i-- {
-			handler = r.middleware[i](handler)
-		}
+	if best == nil {
+		return nil, nil, methodMismatch
+	}
+	if best.exact {
+		return best, best.segmentParams(path), false
+	}
+	return best, best.match(path), false
+}
 
-		handler.ServeHTTP(w, req.WithContext(ctx))
-		return
+// allowedMethods returns the sorted methods that can be used on host and path, for
+// the Allow header. It includes HEAD for GET routes with WithAutoHead and OPTIONS with
+// WithAutoOptions, as those are synthesized by the router. notAllowed is the handler
+// set with Route.OnMethodNotAllowed for the first matching template that has one.
+// The caller must hold r.mu.
+func (r *Router) allowedMethods(host, path string) (methods []string, notAllowed http.HandlerFunc) {
+	set := make(map[string]bool)
+	var notAllowedSeq uint64
+	if r.tree != nil {
+		r.tree.visit(path, false, r.caseInsensitive, func(route *Route, exact bool) {
+			if !route.matchesHost(host) || (!exact && !route.path.MatchString(path)) {
+				return
+			}
+			if handler := r.methodNotAllowedHandlers[route.template]; handler != nil && (notAllowed == nil || route.seq < notAllowedSeq) {
+				notAllowed, notAllowedSeq = handler, route.seq
+			}
+			if route.method == MethodAny {
+				// A MethodAny route accepts every method it does not exclude
+				for _, method := range standardMethods {
+					if !route.excludedMethods[method] {
+						set[method] = true
+					}
+				}
+				return
+			}
+			set[route.method] = true
+			if route.method == http.MethodGet && r.autoHead {
+				set[http.MethodHead] = true
+			}
+		})
+	}
+	if len(set) > 0 && r.autoOptions {
+		set[http.MethodOptions] = true
 	}
 
-	if methodMismatch {
-		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
-		return
+	methods = make([]string, 0, len(set))
+	for method := range set {
+		methods = append(methods, method)
 	}
+	sort.Strings(methods)
+	return methods, notAllowed
+}
 
-	r.NotFoundHandler.ServeHTTP(w, req)
+/*
+Unregister removes the route registered for the given method and path template,
+reporting whether one was removed. It is safe to call while the router is serving
+requests; requests already dispatched to the removed route complete normally, and
+subsequent requests no longer match it.
+
+	router.HandleRoute(http.MethodGet, "/plugins/foo", fooHandler)
+	// ...
+	router.Unregister(http.MethodGet, "/plugins/foo")
+*/
+func (r *Router) Unregister(method, template string) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for i, route := range r.routes {
+		if route.method == method && route.template == template {
+			// Copy rather than shift in place so previously read slices are never mutated
+			routes := make([]*Route, 0, len(r.routes)-1)
+			routes = append(routes, r.routes[:i]...)
+			r.routes = append(routes, r.routes[i+1:]...)
+			r.tree.remove(route)
+
+			if route.name != "" && r.namedRoutes[route.name] == route {
+				delete(r.namedRoutes, route.name)
+			}
+
+			// Index a remaining route registered for the same method and path, if any
+			key := r.staticRouteKey(method, template)
+			if r.staticRoutes[key] == route {
+				delete(r.staticRoutes, key)
+				for _, remaining := range r.routes {
+					if remaining.method == method && remaining.template == template {
+						r.indexStaticRoute(remaining)
+						break
+					}
+				}
+			}
+			return true
+		}
+	}
+	return false
 }
 
 /*
@@ -275,7 +900,50 @@ It chains the middleware functions to create a new handler that executes them in
 the given order before executing the main handler.
 */
 func (r *Router) Use(middleware ...func(http.Handler) http.Handler) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
 	r.middleware = append(r.middleware, middleware...)
+	for _, mw := range middleware {
+		r.middlewareNames = append(r.middlewareNames, middlewareName(mw))
+	}
+}
+
+/*
+UseForMethods registers a middleware function that only runs for requests using one
+of the given HTTP methods, such as CSRF protection for state-changing methods.
+Requests with other methods bypass it and go straight to the next handler.
+
+	router.UseForMethods([]string{http.MethodPost, http.MethodPut, http.MethodDelete}, csrf)
+*/
+func (r *Router) UseForMethods(methods []string, middleware func(http.Handler) http.Handler) {
+	allowed := make(map[string]bool, len(methods))
+	for _, method := range methods {
+		allowed[method] = true
+	}
+
+	r.UseNamed(middlewareName(middleware), func(next http.Handler) http.Handler {
+		wrapped := middleware(next)
+		return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			if allowed[req.Method] {
+				wrapped.ServeHTTP(w, req)
+				return
+			}
+			next.ServeHTTP(w, req)
+		})
+	})
+}
+
+/*
+MatchedPrefix returns the host or path prefix of the subrouter that handled the
+request, which is useful for labeling metrics in composed applications. Path
+prefixes of nested subrouters are joined, so a request served by a "/v1" subrouter
+of an "/api" subrouter returns "/api/v1". Requests served by the top-level router
+return an empty string.
+*/
+func MatchedPrefix(req *http.Request) string {
+	prefix, _ := req.Context().Value(matchedPrefixKey).(string)
+	return prefix
 }
 
 // CurrentRoute returns the matched route for the current request, if any.
@@ -288,3 +956,33 @@ func CurrentRoute(r *http.Request) *Route {
 	}
 	return nil
 }
+
+/*
+RawWildcard returns the remainder of the request URL captured by the wildcard of
+a route like "/proxy/*", as sent by the client: still escaped and followed by the
+query string, if any. Unlike the "path" parameter, which holds the decoded path
+only, it can be forwarded as is by a reverse proxy. It returns an empty string if
+the request was not matched by a wildcard route.
+
+	// GET /proxy/a%2Fb/c?key=value
+	muxer.RawWildcard(r) // "a%2Fb/c?key=value"
+	muxer.Params(r)["path"] // "a/b/c"
+*/
+func RawWildcard(r *http.Request) string {
+	route := CurrentRoute(r)
+	if route == nil || !strings.Contains(route.template, "*") {
+		return ""
+	}
+
+	base := strings.TrimSuffix(strings.TrimSuffix(route.template, "*"), "/")
+	escaped := r.URL.EscapedPath()
+	if len(escaped) <= len(base)+1 {
+		return ""
+	}
+
+	raw := escaped[len(base)+1:]
+	if r.URL.RawQuery != "" || r.URL.ForceQuery {
+		raw += "?" + r.URL.RawQuery
+	}
+	return raw
+}
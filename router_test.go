@@ -1,16 +1,22 @@
 package muxer
 
 import (
+	"bytes"
+	"compress/gzip"
 	"context"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"io"
+	"log"
 	"net/http"
 	"net/http/httptest"
+	"os"
 	"reflect"
 	"regexp"
 	"strings"
 	"testing"
+	"time"
 
 	. "github.com/shellfu/muxer/middleware"
 )
@@ -520,6 +526,28 @@ func TestEnableCORSOption(t *testing.T) {
 	}
 }
 
+func TestRouteParams(t *testing.T) {
+	router := NewRouter()
+	handler := func(w http.ResponseWriter, r *http.Request) {}
+
+	tests := []struct {
+		path     string
+		expected []string
+	}{
+		{"/users/:id/posts/:slug", []string{"id", "slug"}},
+		{"/proxy/:target{greedy}/info", []string{"target"}},
+		{"/static/*", []string{"path"}},
+		{"/health", []string{}},
+	}
+
+	for _, tc := range tests {
+		params := router.HandleRoute(http.MethodGet, tc.path, handler).Params()
+		if !reflect.DeepEqual(params, tc.expected) {
+			t.Errorf("unexpected params for %s: expected=%v, actual=%v", tc.path, tc.expected, params)
+		}
+	}
+}
+
 func TestPathTemplate(t *testing.T) {
 	tests := []struct {
 		name           string
@@ -732,3 +760,1674 @@ func TestWildcardRoutes(t *testing.T) {
 		})
 	}
 }
+
+func TestRawWildcard(t *testing.T) {
+	var raw, param string
+	handler := func(w http.ResponseWriter, r *http.Request) {
+		raw, param = RawWildcard(r), Params(r)["path"]
+	}
+
+	router := NewRouter()
+	router.HandleRoute(http.MethodGet, "/proxy/*", handler)
+	router.HandleRoute(http.MethodGet, "/users/:id", handler)
+	router.Subrouter("/api").HandleRoute(http.MethodGet, "/files/*", handler)
+
+	tests := []struct {
+		path          string
+		expectedRaw   string
+		expectedParam string
+	}{
+		{"/proxy/search?q=a+b&page=2", "search?q=a+b&page=2", "search"},
+		{"/proxy/a%2Fb/c?key=value", "a%2Fb/c?key=value", "a/b/c"},
+		{"/proxy/plain", "plain", "plain"},
+		{"/api/files/docs/readme.md?raw=1", "docs/readme.md?raw=1", "docs/readme.md"},
+		{"/api/files/a%2Fb/c?key=value", "a%2Fb/c?key=value", "a/b/c"},
+		{"/%61pi/files/a%2Fb", "a%2Fb", "a/b"},
+		{"/users/42?key=value", "", ""},
+	}
+
+	for _, tc := range tests {
+		raw, param = "", ""
+		router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, tc.path, nil))
+
+		if raw != tc.expectedRaw {
+			t.Errorf("unexpected raw wildcard for %s: expected=%q, actual=%q", tc.path, tc.expectedRaw, raw)
+		}
+		if param != tc.expectedParam {
+			t.Errorf("unexpected path param for %s: expected=%q, actual=%q", tc.path, tc.expectedParam, param)
+		}
+	}
+}
+
+func TestRoute_Compress(t *testing.T) {
+	router := NewRouter()
+
+	body := strings.Repeat("large payload ", 100)
+	handler := func(w http.ResponseWriter, r *http.Request) {
+		if _, err := w.Write([]byte(body)); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+		}
+	}
+
+	router.HandleRoute(http.MethodGet, "/reports/:id", handler).Compress()
+	router.HandleRoute(http.MethodGet, "/status", handler)
+
+	tests := []struct {
+		name             string
+		path             string
+		expectedEncoding string
+	}{
+		{"compress-enabled route", "/reports/123", "gzip"},
+		{"sibling route", "/status", ""},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, tc.path, nil)
+			req.Header.Set("Accept-Encoding", "gzip")
+			w := httptest.NewRecorder()
+
+			router.ServeHTTP(w, req)
+
+			if got := w.Header().Get("Content-Encoding"); got != tc.expectedEncoding {
+				t.Errorf("expected Content-Encoding %q, got %q", tc.expectedEncoding, got)
+			}
+
+			var got []byte
+			if tc.expectedEncoding == "gzip" {
+				reader, err := gzip.NewReader(w.Body)
+				if err != nil {
+					t.Fatal(err)
+				}
+				defer reader.Close()
+				if got, err = io.ReadAll(reader); err != nil {
+					t.Fatal(err)
+				}
+			} else {
+				got = w.Body.Bytes()
+			}
+
+			if string(got) != body {
+				t.Errorf("unexpected response body length: expected=%d, actual=%d", len(body), len(got))
+			}
+		})
+	}
+}
+
+func TestExplicitOptionsRouteBypassesCORS(t *testing.T) {
+	router := NewRouter()
+	router.Use(CORS(
+		WithAllowedOrigins("http://example.com"),
+		WithAllowedMethods(http.MethodGet, http.MethodOptions),
+	))
+
+	router.HandleRoute(http.MethodOptions, "/capabilities", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		if _, err := w.Write([]byte(`{"versions":["v1","v2"]}`)); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+		}
+	})
+
+	req := httptest.NewRequest(http.MethodOptions, "/capabilities", nil)
+	req.Header.Set("Origin", "http://example.com")
+	w := httptest.NewRecorder()
+
+	router.ServeHTTP(w, req)
+
+	expectedBody := `{"versions":["v1","v2"]}`
+	if w.Body.String() != expectedBody {
+		t.Errorf("expected body %q, got %q", expectedBody, w.Body.String())
+	}
+	if got := w.Header().Get("Content-Type"); got != "application/json" {
+		t.Errorf("expected Content-Type %q, got %q", "application/json", got)
+	}
+	if got := w.Header().Get("Access-Control-Allow-Origin"); got != "http://example.com" {
+		t.Errorf("expected Access-Control-Allow-Origin %q, got %q", "http://example.com", got)
+	}
+}
+
+func TestRoute_ValidateBody(t *testing.T) {
+	router := NewRouter(WithMaxRequestBodySize(64))
+
+	var received string
+	router.HandleRoute(http.MethodPost, "/users", func(w http.ResponseWriter, r *http.Request) {
+		body, err := io.ReadAll(r.Body)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		received = string(body)
+		w.WriteHeader(http.StatusCreated)
+	}).ValidateBody(func(body []byte) error {
+		if !strings.Contains(string(body), `"name"`) {
+			return errors.New("missing required field: name")
+		}
+		return nil
+	})
+
+	testCases := []struct {
+		name             string
+		body             string
+		expectedCode     int
+		expectedBody     string
+		expectedReceived string
+	}{
+		{"passing body", `{"name":"gopher"}`, http.StatusCreated, "", `{"name":"gopher"}`},
+		{"failing body", `{"age":12}`, http.StatusUnprocessableEntity, "missing required field: name", ""},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			received = ""
+			req := httptest.NewRequest(http.MethodPost, "/users", strings.NewReader(tc.body))
+			w := httptest.NewRecorder()
+
+			router.ServeHTTP(w, req)
+
+			if w.Code != tc.expectedCode {
+				t.Errorf("unexpected status code: expected=%d, actual=%d", tc.expectedCode, w.Code)
+			}
+			if strings.TrimSpace(w.Body.String()) != tc.expectedBody {
+				t.Errorf("unexpected response body: expected=%s, actual=%s", tc.expectedBody, w.Body.String())
+			}
+			if received != tc.expectedReceived {
+				t.Errorf("unexpected body received by handler: expected=%s, actual=%s", tc.expectedReceived, received)
+			}
+		})
+	}
+
+	t.Run("body over the limit", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodPost, "/users", strings.NewReader(`{"name":"`+strings.Repeat("a", 64)+`"}`))
+		req.ContentLength = -1
+		w := httptest.NewRecorder()
+
+		router.ServeHTTP(w, req)
+
+		if w.Code != http.StatusRequestEntityTooLarge {
+			t.Errorf("unexpected status code: expected=%d, actual=%d", http.StatusRequestEntityTooLarge, w.Code)
+		}
+	})
+}
+
+func TestRoute_Hits(t *testing.T) {
+	router := NewRouter()
+
+	users := router.HandleRoute(http.MethodGet, "/users/:id", func(w http.ResponseWriter, r *http.Request) {})
+	health := router.HandleRoute(http.MethodGet, "/health", func(w http.ResponseWriter, r *http.Request) {})
+
+	for i := 0; i < 5; i++ {
+		req := httptest.NewRequest(http.MethodGet, fmt.Sprintf("/users/%d", i), nil)
+		router.ServeHTTP(httptest.NewRecorder(), req)
+	}
+
+	// Unmatched requests must not be counted
+	router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/unknown", nil))
+
+	if got := users.Hits(); got != 5 {
+		t.Errorf("expected 5 hits for /users/:id, got %d", got)
+	}
+	if got := health.Hits(); got != 0 {
+		t.Errorf("expected 0 hits for /health, got %d", got)
+	}
+
+	hits := make(map[string]int64)
+	for _, info := range router.Routes() {
+		hits[info.Template] = info.Hits
+	}
+	if expected := map[string]int64{"/users/:id": 5, "/health": 0}; !reflect.DeepEqual(hits, expected) {
+		t.Errorf("unexpected route hits: expected=%v, actual=%v", expected, hits)
+	}
+}
+
+func TestRoute_MaxConcurrent(t *testing.T) {
+	const limit = 2
+
+	router := NewRouter()
+
+	started := make(chan struct{})
+	release := make(chan struct{})
+	router.HandleRoute(http.MethodGet, "/reports", func(w http.ResponseWriter, r *http.Request) {
+		started <- struct{}{}
+		<-release
+		w.WriteHeader(http.StatusOK)
+	}).MaxConcurrent(limit)
+
+	codes := make(chan int, limit)
+	for i := 0; i < limit; i++ {
+		go func() {
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/reports", nil))
+			codes <- w.Code
+		}()
+	}
+
+	// Wait until every slot is taken before sending the overflow request
+	for i := 0; i < limit; i++ {
+		<-started
+	}
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/reports", nil))
+	if w.Code != http.StatusServiceUnavailable {
+		t.Errorf("expected overflow request to get %d, got %d", http.StatusServiceUnavailable, w.Code)
+	}
+
+	close(release)
+	for i := 0; i < limit; i++ {
+		if code := <-codes; code != http.StatusOK {
+			t.Errorf("expected in-flight request to get %d, got %d", http.StatusOK, code)
+		}
+	}
+
+	// Slots are released once the in-flight requests complete
+	go func() { <-started }()
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/reports", nil))
+	if w.Code != http.StatusOK {
+		t.Errorf("expected request after release to get %d, got %d", http.StatusOK, w.Code)
+	}
+}
+
+func TestWithNotFoundMessage(t *testing.T) {
+	router := NewRouter(WithNotFoundMessage(http.StatusNotFound, `{"error":"not found"}`, "application/json"))
+	router.HandleRoute(http.MethodGet, "/users/:id", func(w http.ResponseWriter, r *http.Request) {})
+
+	req := httptest.NewRequest(http.MethodGet, "/unknown", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	if w.Code != http.StatusNotFound {
+		t.Errorf("Expected status code: %d. Got: %d", http.StatusNotFound, w.Code)
+	}
+	if got := w.Header().Get("Content-Type"); got != "application/json" {
+		t.Errorf("Expected content type: %s. Got: %s", "application/json", got)
+	}
+	if got := w.Body.String(); got != `{"error":"not found"}` {
+		t.Errorf("Expected response body: %s. Got: %s", `{"error":"not found"}`, got)
+	}
+}
+
+func TestRouter_Unregister(t *testing.T) {
+	router := NewRouter()
+
+	router.HandleRoute(http.MethodGet, "/plugins/foo", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+	router.HandleRoute(http.MethodGet, "/plugins/bar", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+
+	// Serve and register concurrently with the unregistration to exercise the locking
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for i := 0; i < 100; i++ {
+			router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/plugins/bar", nil))
+			router.HandleRoute(http.MethodGet, fmt.Sprintf("/plugins/extra%d", i), func(w http.ResponseWriter, r *http.Request) {})
+		}
+	}()
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/plugins/foo", nil))
+	if w.Code != http.StatusOK {
+		t.Errorf("unexpected status code before unregister: expected=%d, actual=%d", http.StatusOK, w.Code)
+	}
+
+	if !router.Unregister(http.MethodGet, "/plugins/foo") {
+		t.Error("expected Unregister to remove the route")
+	}
+	if router.Unregister(http.MethodGet, "/plugins/foo") {
+		t.Error("expected second Unregister to report no route removed")
+	}
+	<-done
+
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/plugins/foo", nil))
+	if w.Code != http.StatusNotFound {
+		t.Errorf("unexpected status code after unregister: expected=%d, actual=%d", http.StatusNotFound, w.Code)
+	}
+
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/plugins/bar", nil))
+	if w.Code != http.StatusOK {
+		t.Errorf("unexpected status code for remaining route: expected=%d, actual=%d", http.StatusOK, w.Code)
+	}
+}
+
+func TestWithHeadLiveness(t *testing.T) {
+	tests := []struct {
+		name         string
+		paths        []string
+		method       string
+		path         string
+		expectedCode int
+		expectedBody string
+	}{
+		{"HEAD to liveness path", []string{"/", "/healthz"}, http.MethodHead, "/healthz", http.StatusOK, ""},
+		{"GET still routes normally", []string{"/", "/healthz"}, http.MethodGet, "/", http.StatusOK, "index"},
+		{"HEAD to other path is routed", []string{"/", "/healthz"}, http.MethodHead, "/other", http.StatusNotFound, "404 page not found\n"},
+		{"HEAD to any path", nil, http.MethodHead, "/anything/at/all", http.StatusOK, ""},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			router := NewRouter(WithHeadLiveness(tc.paths...))
+			router.HandleRoute(http.MethodGet, "/", func(w http.ResponseWriter, r *http.Request) {
+				w.Write([]byte("index")) // nolint: errcheck
+			})
+
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, httptest.NewRequest(tc.method, tc.path, nil))
+
+			if w.Code != tc.expectedCode {
+				t.Errorf("unexpected status code: expected=%d, actual=%d", tc.expectedCode, w.Code)
+			}
+			if w.Body.String() != tc.expectedBody {
+				t.Errorf("unexpected response body: expected=%q, actual=%q", tc.expectedBody, w.Body.String())
+			}
+		})
+	}
+}
+
+func TestRouter_UseForMethods(t *testing.T) {
+	router := NewRouter()
+
+	router.UseForMethods([]string{http.MethodPost, http.MethodPut}, func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("X-CSRF-Checked", "true")
+			next.ServeHTTP(w, r)
+		})
+	})
+
+	handlerFunc := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {})
+	router.HandleRoute(http.MethodGet, "/form", handlerFunc)
+	router.HandleRoute(http.MethodPost, "/form", handlerFunc)
+
+	testCases := []struct {
+		method         string
+		expectedHeader string
+	}{
+		{http.MethodPost, "true"},
+		{http.MethodGet, ""},
+	}
+
+	for _, tc := range testCases {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(tc.method, "/form", nil))
+
+		if got := w.Header().Get("X-CSRF-Checked"); got != tc.expectedHeader {
+			t.Errorf("unexpected middleware header for %s: expected=%q, actual=%q", tc.method, tc.expectedHeader, got)
+		}
+	}
+}
+
+func TestRouter_HandleExcept(t *testing.T) {
+	router := NewRouter()
+
+	router.HandleExcept("/proxy/*", []string{http.MethodTrace, http.MethodConnect}, func(w http.ResponseWriter, r *http.Request) {
+		if _, err := w.Write([]byte(r.Method + " " + router.Params(r)["path"])); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+		}
+	})
+
+	testCases := []struct {
+		method       string
+		expectedCode int
+		expectedBody string
+	}{
+		{http.MethodGet, http.StatusOK, "GET a/b"},
+		{http.MethodPost, http.StatusOK, "POST a/b"},
+		{http.MethodPatch, http.StatusOK, "PATCH a/b"},
+		{http.MethodTrace, http.StatusMethodNotAllowed, "Method not allowed\n"},
+		{http.MethodConnect, http.StatusMethodNotAllowed, "Method not allowed\n"},
+	}
+
+	for _, tc := range testCases {
+		req := httptest.NewRequest(tc.method, "/proxy/a/b", nil)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		if w.Code != tc.expectedCode {
+			t.Errorf("unexpected status code for %s: expected=%d, actual=%d", tc.method, tc.expectedCode, w.Code)
+		}
+		if w.Body.String() != tc.expectedBody {
+			t.Errorf("unexpected response body for %s: expected=%q, actual=%q", tc.method, tc.expectedBody, w.Body.String())
+		}
+		if tc.expectedCode == http.StatusMethodNotAllowed {
+			expected := "DELETE, GET, HEAD, OPTIONS, PATCH, POST, PUT"
+			if allow := w.Header().Get("Allow"); allow != expected {
+				t.Errorf("unexpected Allow header for %s: expected=%q, actual=%q", tc.method, expected, allow)
+			}
+		}
+	}
+}
+
+func TestGreedyParams(t *testing.T) {
+	router := NewRouter()
+
+	var capturedParams map[string]string
+	router.HandleRoute(http.MethodGet, "/proxy/:target{greedy}/info", func(w http.ResponseWriter, r *http.Request) {
+		capturedParams = router.Params(r)
+	})
+
+	testCases := []struct {
+		path           string
+		expectedCode   int
+		expectedParams map[string]string
+	}{
+		{"/proxy/a/b/c/info", http.StatusOK, map[string]string{"target": "a/b/c"}},
+		{"/proxy/a/info", http.StatusOK, map[string]string{"target": "a"}},
+		{"/proxy/a/info/info", http.StatusOK, map[string]string{"target": "a/info"}},
+		{"/proxy/info", http.StatusNotFound, nil},
+		{"/proxy/a/b/c", http.StatusNotFound, nil},
+	}
+
+	for _, tc := range testCases {
+		capturedParams = nil
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, tc.path, nil))
+
+		if w.Code != tc.expectedCode {
+			t.Errorf("unexpected status code for %s: expected=%d, actual=%d", tc.path, tc.expectedCode, w.Code)
+		}
+		if !reflect.DeepEqual(capturedParams, tc.expectedParams) {
+			t.Errorf("unexpected params for %s: expected=%v, actual=%v", tc.path, tc.expectedParams, capturedParams)
+		}
+	}
+}
+
+// panicLogger records the values logged through Println.
+type panicLogger struct {
+	logged []interface{}
+}
+
+func (l *panicLogger) Println(v ...interface{}) {
+	l.logged = append(l.logged, v...)
+}
+
+func TestWithRecovery(t *testing.T) {
+	testCases := []struct {
+		name string
+		path string
+	}{
+		{"top-level route", "/panic"},
+		{"subrouter route", "/api/panic"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			logger := &panicLogger{}
+			router := NewRouter(WithRecovery(logger, false))
+
+			panicking := func(w http.ResponseWriter, r *http.Request) {
+				panic("danger danger danger!")
+			}
+			router.HandleRoute(http.MethodGet, "/panic", panicking)
+			router.Subrouter("/api").HandleRoute(http.MethodGet, "/panic", panicking)
+
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, tc.path, nil))
+
+			if w.Code != http.StatusInternalServerError {
+				t.Errorf("unexpected status code: expected=%d, actual=%d", http.StatusInternalServerError, w.Code)
+			}
+			if len(logger.logged) != 1 || logger.logged[0] != "danger danger danger!" {
+				t.Errorf("expected logger to receive the panic value, got %v", logger.logged)
+			}
+		})
+	}
+}
+
+func TestWithRecovery_PanicRenderer(t *testing.T) {
+	router := NewRouter(WithRecovery(&panicLogger{}, false, WithPanicRenderer(JSONPanicRenderer)))
+	router.HandleRoute(http.MethodGet, "/panic", func(w http.ResponseWriter, r *http.Request) {
+		panic("danger danger danger!")
+	})
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/panic", nil))
+
+	if w.Code != http.StatusInternalServerError {
+		t.Errorf("unexpected status code: expected=%d, actual=%d", http.StatusInternalServerError, w.Code)
+	}
+
+	var body map[string]string
+	if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil {
+		t.Fatalf("failed to decode response body %q: %v", w.Body.String(), err)
+	}
+	if body["method"] != http.MethodGet || body["path"] != "/panic" {
+		t.Errorf("unexpected response body: %v", body)
+	}
+}
+
+func TestWithRecovery_HTTPErrorPanic(t *testing.T) {
+	router := NewRouter(WithRecovery(&panicLogger{}, false))
+	router.HandleRoute(http.MethodGet, "/admin", func(w http.ResponseWriter, r *http.Request) {
+		panic(HTTPError{Status: http.StatusForbidden})
+	})
+	router.HandleRoute(http.MethodGet, "/reports", func(w http.ResponseWriter, r *http.Request) {
+		panic(&HTTPError{Status: http.StatusServiceUnavailable, Message: "reports are being rebuilt"})
+	})
+	router.HandleRoute(http.MethodGet, "/panic", func(w http.ResponseWriter, r *http.Request) {
+		panic("danger danger danger!")
+	})
+
+	tests := []struct {
+		path         string
+		expectedCode int
+		expectedBody string
+	}{
+		{"/admin", http.StatusForbidden, "Forbidden\n"},
+		{"/reports", http.StatusServiceUnavailable, "reports are being rebuilt\n"},
+		{"/panic", http.StatusInternalServerError, ""},
+	}
+
+	for _, tc := range tests {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, tc.path, nil))
+
+		if w.Code != tc.expectedCode {
+			t.Errorf("unexpected status code for %s: expected=%d, actual=%d", tc.path, tc.expectedCode, w.Code)
+		}
+		if w.Body.String() != tc.expectedBody {
+			t.Errorf("unexpected response body for %s: expected=%q, actual=%q", tc.path, tc.expectedBody, w.Body.String())
+		}
+	}
+
+	// The RecoveryHandler middleware renders the message of an HTTPError too
+	withMiddleware := NewRouter()
+	withMiddleware.Use(RecoveryHandler(&panicLogger{}, false))
+	withMiddleware.HandleRoute(http.MethodGet, "/quota", func(w http.ResponseWriter, r *http.Request) {
+		panic(HTTPError{Status: http.StatusTooManyRequests, Message: "quota exceeded"})
+	})
+
+	w := httptest.NewRecorder()
+	withMiddleware.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/quota", nil))
+
+	if w.Code != http.StatusTooManyRequests {
+		t.Errorf("unexpected status code: expected=%d, actual=%d", http.StatusTooManyRequests, w.Code)
+	}
+	if expected := "quota exceeded\n"; w.Body.String() != expected {
+		t.Errorf("unexpected response body: expected=%q, actual=%q", expected, w.Body.String())
+	}
+}
+
+func TestMatchedPrefix(t *testing.T) {
+	router := NewRouter()
+
+	var prefix string
+	handler := func(w http.ResponseWriter, r *http.Request) {
+		prefix = MatchedPrefix(r)
+	}
+
+	router.HandleRoute(http.MethodGet, "/health", handler)
+	api := router.Subrouter("/api")
+	api.HandleRoute(http.MethodGet, "/users/:id", handler)
+	api.Subrouter("/v1").HandleRoute(http.MethodGet, "/orders", handler)
+
+	testCases := []struct {
+		path           string
+		expectedPrefix string
+	}{
+		{"/health", ""},
+		{"/api/users/123", "/api"},
+		{"/api/v1/orders", "/api/v1"},
+	}
+
+	for _, tc := range testCases {
+		prefix = "unset"
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, tc.path, nil))
+
+		if w.Code != http.StatusOK {
+			t.Errorf("unexpected status code for %s: expected=%d, actual=%d", tc.path, http.StatusOK, w.Code)
+		}
+		if prefix != tc.expectedPrefix {
+			t.Errorf("unexpected matched prefix for %s: expected=%q, actual=%q", tc.path, tc.expectedPrefix, prefix)
+		}
+	}
+}
+
+func TestAllowHeader(t *testing.T) {
+	handler := func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Length", "5")
+		w.Write([]byte("hello")) // nolint: errcheck
+	}
+
+	tests := []struct {
+		name          string
+		options       []RouterOption
+		method        string
+		path          string
+		expectedCode  int
+		expectedAllow string
+		expectedBody  string
+	}{
+		{"405 lists registered methods", nil, http.MethodDelete, "/users/1", http.StatusMethodNotAllowed, "GET, POST", "Method not allowed\n"},
+		{"405 lists synthesized methods", []RouterOption{WithAutoOptions(), WithAutoHead()}, http.MethodDelete, "/users/1", http.StatusMethodNotAllowed, "GET, HEAD, OPTIONS, POST", "Method not allowed\n"},
+		{"auto OPTIONS", []RouterOption{WithAutoOptions(), WithAutoHead()}, http.MethodOptions, "/status", http.StatusNoContent, "GET, HEAD, OPTIONS", ""},
+		{"OPTIONS without auto options", nil, http.MethodOptions, "/status", http.StatusMethodNotAllowed, "GET", "Method not allowed\n"},
+		{"auto HEAD", []RouterOption{WithAutoHead()}, http.MethodHead, "/status", http.StatusOK, "", ""},
+		{"HEAD without auto head", nil, http.MethodHead, "/status", http.StatusMethodNotAllowed, "GET", "Method not allowed\n"},
+		{"unknown path", []RouterOption{WithAutoOptions()}, http.MethodOptions, "/unknown", http.StatusNotFound, "", "404 page not found\n"},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			router := NewRouter(tc.options...)
+			router.HandleRoute(http.MethodGet, "/status", handler)
+			router.HandleRoute(http.MethodGet, "/users/:id", handler)
+			router.HandleRoute(http.MethodPost, "/users/:id", handler)
+
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, httptest.NewRequest(tc.method, tc.path, nil))
+
+			if w.Code != tc.expectedCode {
+				t.Errorf("unexpected status code: expected=%d, actual=%d", tc.expectedCode, w.Code)
+			}
+			if allow := w.Header().Get("Allow"); allow != tc.expectedAllow {
+				t.Errorf("unexpected Allow header: expected=%q, actual=%q", tc.expectedAllow, allow)
+			}
+			if body := w.Body.String(); body != tc.expectedBody {
+				t.Errorf("unexpected response body: expected=%q, actual=%q", tc.expectedBody, body)
+			}
+		})
+	}
+}
+
+func TestRouteHost(t *testing.T) {
+	router := NewRouter()
+	router.HandleRoute(http.MethodGet, "/dashboard", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("admin")) // nolint: errcheck
+	}).Host("admin.example.com")
+	router.HandleRoute(http.MethodGet, "/tenant", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("tenant")) // nolint: errcheck
+	}).Host("*.example.com")
+	router.HandleRoute(http.MethodGet, "/", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("index")) // nolint: errcheck
+	})
+
+	tests := []struct {
+		host         string
+		path         string
+		expectedCode int
+		expectedBody string
+	}{
+		{"admin.example.com", "/dashboard", http.StatusOK, "admin"},
+		{"ADMIN.example.com:8080", "/dashboard", http.StatusOK, "admin"},
+		{"www.example.com", "/dashboard", http.StatusNotFound, "404 page not found\n"},
+		{"acme.example.com", "/tenant", http.StatusOK, "tenant"},
+		{"example.com", "/tenant", http.StatusNotFound, "404 page not found\n"},
+		{"www.example.com", "/", http.StatusOK, "index"},
+	}
+
+	for _, tc := range tests {
+		req := httptest.NewRequest(http.MethodGet, tc.path, nil)
+		req.Host = tc.host
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		if w.Code != tc.expectedCode {
+			t.Errorf("unexpected status code for %s%s: expected=%d, actual=%d", tc.host, tc.path, tc.expectedCode, w.Code)
+		}
+		if body := w.Body.String(); body != tc.expectedBody {
+			t.Errorf("unexpected response body for %s%s: expected=%q, actual=%q", tc.host, tc.path, tc.expectedBody, body)
+		}
+	}
+}
+
+func TestStaticRoutes(t *testing.T) {
+	router := NewRouter()
+
+	var matched string
+	route := func(name string) http.HandlerFunc {
+		return func(w http.ResponseWriter, r *http.Request) {
+			matched = name
+		}
+	}
+
+	router.HandleRoute(http.MethodGet, "/users/:id", route("param"))
+	router.HandleRoute(http.MethodGet, "/users/me", route("first"))
+	router.HandleRoute(http.MethodGet, "/users/me", route("second"))
+
+	serve := func() {
+		matched = ""
+		router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/users/me", nil))
+	}
+
+	serve()
+	if matched != "first" {
+		t.Errorf("unexpected route: expected=%q, actual=%q", "first", matched)
+	}
+
+	router.Unregister(http.MethodGet, "/users/me")
+	serve()
+	if matched != "second" {
+		t.Errorf("unexpected route after unregister: expected=%q, actual=%q", "second", matched)
+	}
+
+	router.Unregister(http.MethodGet, "/users/me")
+	serve()
+	if matched != "param" {
+		t.Errorf("unexpected route after unregistering static routes: expected=%q, actual=%q", "param", matched)
+	}
+}
+
+func TestRouteOn(t *testing.T) {
+	respond := func(body string) http.HandlerFunc {
+		return func(w http.ResponseWriter, r *http.Request) {
+			w.Write([]byte(body)) // nolint: errcheck
+		}
+	}
+
+	router := NewRouter()
+	router.HandleRoute(http.MethodPost, "/uploads", nil).
+		On("application/json", respond("json")).
+		On("multipart/form-data", respond("multipart"))
+	router.HandleRoute(http.MethodPost, "/documents", respond("base")).
+		On("text/*", respond("text"))
+
+	tests := []struct {
+		path         string
+		contentType  string
+		expectedCode int
+		expectedBody string
+	}{
+		{"/uploads", "application/json", http.StatusOK, "json"},
+		{"/uploads", "Application/JSON; charset=utf-8", http.StatusOK, "json"},
+		{"/uploads", "multipart/form-data; boundary=xyz", http.StatusOK, "multipart"},
+		{"/uploads", "text/plain", http.StatusUnsupportedMediaType, "Unsupported Media Type\n"},
+		{"/uploads", "", http.StatusUnsupportedMediaType, "Unsupported Media Type\n"},
+		{"/documents", "text/markdown", http.StatusOK, "text"},
+		{"/documents", "application/pdf", http.StatusOK, "base"},
+	}
+
+	for _, tc := range tests {
+		req := httptest.NewRequest(http.MethodPost, tc.path, strings.NewReader("payload"))
+		if tc.contentType != "" {
+			req.Header.Set("Content-Type", tc.contentType)
+		}
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		if w.Code != tc.expectedCode {
+			t.Errorf("unexpected status code for %s %q: expected=%d, actual=%d", tc.path, tc.contentType, tc.expectedCode, w.Code)
+		}
+		if body := w.Body.String(); body != tc.expectedBody {
+			t.Errorf("unexpected response body for %s %q: expected=%q, actual=%q", tc.path, tc.contentType, tc.expectedBody, body)
+		}
+	}
+}
+
+func TestRouteProduces(t *testing.T) {
+	respond := func(body string) http.HandlerFunc {
+		return func(w http.ResponseWriter, r *http.Request) {
+			w.Write([]byte(body)) // nolint: errcheck
+		}
+	}
+
+	router := NewRouter()
+	router.HandleRoute(http.MethodGet, "/users/:id", nil).
+		Produces("application/json", respond("json")).
+		Produces("application/xml", respond("xml"))
+
+	tests := []struct {
+		accept       string
+		expectedCode int
+		expectedBody string
+	}{
+		{"application/json", http.StatusOK, "json"},
+		{"application/xml", http.StatusOK, "xml"},
+		{"text/html, application/xml;q=0.9, */*;q=0.8", http.StatusOK, "xml"},
+		{"application/json;q=0.5, application/xml", http.StatusOK, "xml"},
+		{"application/*", http.StatusOK, "json"},
+		{"*/*;q=0.1, application/json;q=0", http.StatusOK, "xml"},
+		{"", http.StatusOK, "json"},
+		{"text/html", http.StatusNotAcceptable, "Not Acceptable\n"},
+	}
+
+	for _, tc := range tests {
+		req := httptest.NewRequest(http.MethodGet, "/users/1", nil)
+		if tc.accept != "" {
+			req.Header.Set("Accept", tc.accept)
+		}
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		if w.Code != tc.expectedCode {
+			t.Errorf("unexpected status code for %q: expected=%d, actual=%d", tc.accept, tc.expectedCode, w.Code)
+		}
+		if body := w.Body.String(); body != tc.expectedBody {
+			t.Errorf("unexpected response body for %q: expected=%q, actual=%q", tc.accept, tc.expectedBody, body)
+		}
+		if vary := w.Header().Get("Vary"); vary != "Accept" {
+			t.Errorf("unexpected Vary header for %q: expected=%q, actual=%q", tc.accept, "Accept", vary)
+		}
+	}
+}
+
+func TestRouteAlias(t *testing.T) {
+	router := NewRouter()
+
+	var calls []string
+	tag := func(name string) func(http.Handler) http.Handler {
+		return func(next http.Handler) http.Handler {
+			return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				calls = append(calls, name)
+				next.ServeHTTP(w, r)
+			})
+		}
+	}
+
+	route := router.HandleMany(http.MethodGet, []string{"/login", "/signin"}, func(w http.ResponseWriter, r *http.Request) {
+		calls = append(calls, "handler")
+	})
+	route.middleware = append(route.middleware, tag("route-local"))
+	route.Alias("/sign-in")
+
+	for _, path := range []string{"/login", "/signin", "/sign-in"} {
+		calls = nil
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, path, nil))
+
+		if w.Code != http.StatusOK {
+			t.Errorf("unexpected status code for %s: expected=%d, actual=%d", path, http.StatusOK, w.Code)
+		}
+		if !reflect.DeepEqual(calls, []string{"route-local", "handler"}) {
+			t.Errorf("unexpected calls for %s: %v", path, calls)
+		}
+	}
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/signin", nil))
+	if w.Code != http.StatusMethodNotAllowed {
+		t.Errorf("unexpected status code for alias with other method: expected=%d, actual=%d", http.StatusMethodNotAllowed, w.Code)
+	}
+}
+
+func TestWithRouteContextDecorator(t *testing.T) {
+	type nameKey struct{}
+
+	router := NewRouter(WithRouteContextDecorator(func(ctx context.Context, route *Route) context.Context {
+		template, _ := route.PathTemplate()
+		return context.WithValue(ctx, nameKey{}, "handler for "+template)
+	}))
+
+	var name string
+	handler := func(w http.ResponseWriter, r *http.Request) {
+		name, _ = r.Context().Value(nameKey{}).(string)
+	}
+	router.HandleRoute(http.MethodGet, "/users/:id", handler)
+	router.Subrouter("/api").HandleRoute(http.MethodGet, "/orders", handler)
+
+	tests := []struct {
+		path         string
+		expectedName string
+	}{
+		{"/users/1", "handler for /users/:id"},
+		{"/api/orders", "handler for /orders"},
+	}
+
+	for _, tc := range tests {
+		name = ""
+		router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, tc.path, nil))
+
+		if name != tc.expectedName {
+			t.Errorf("unexpected decorated value for %s: expected=%q, actual=%q", tc.path, tc.expectedName, name)
+		}
+	}
+}
+
+func TestWithMaxPathSegments(t *testing.T) {
+	router := NewRouter(WithMaxPathSegments(4))
+	router.HandleRoute(http.MethodGet, "/api/users/:id/posts", func(w http.ResponseWriter, r *http.Request) {})
+	router.HandleRoute(http.MethodGet, "/*", func(w http.ResponseWriter, r *http.Request) {})
+
+	tests := []struct {
+		path         string
+		expectedCode int
+	}{
+		{"/api/users/1/posts", http.StatusOK},
+		{"/api/users/1/posts/", http.StatusOK},
+		{"/a/b/c/d/e", http.StatusRequestURITooLong},
+		{"/" + strings.Repeat("a/", 100), http.StatusRequestURITooLong},
+	}
+
+	for _, tc := range tests {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, tc.path, nil))
+
+		if w.Code != tc.expectedCode {
+			t.Errorf("unexpected status code for %s: expected=%d, actual=%d", tc.path, tc.expectedCode, w.Code)
+		}
+	}
+}
+
+func TestWithRetryAfter(t *testing.T) {
+	router := NewRouter(WithRetryAfter(90500 * time.Millisecond))
+
+	// A route in maintenance reports 503 through HandleErr
+	router.HandleErr(http.MethodGet, "/maintenance", func(w http.ResponseWriter, r *http.Request) error {
+		return HTTPError{Status: http.StatusServiceUnavailable, Message: "down for maintenance"}
+	})
+	router.HandleErr(http.MethodGet, "/forbidden", func(w http.ResponseWriter, r *http.Request) error {
+		return HTTPError{Status: http.StatusForbidden}
+	})
+
+	// A route shedding load while its only slot is taken
+	started := make(chan struct{})
+	release := make(chan struct{})
+	router.HandleRoute(http.MethodGet, "/shed", func(w http.ResponseWriter, r *http.Request) {
+		started <- struct{}{}
+		<-release
+	}).MaxConcurrent(1)
+
+	done := make(chan struct{})
+	go func() {
+		router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/shed", nil))
+		close(done)
+	}()
+	<-started
+	defer func() {
+		close(release)
+		<-done
+	}()
+
+	tests := []struct {
+		path               string
+		expectedCode       int
+		expectedRetryAfter string
+	}{
+		{"/maintenance", http.StatusServiceUnavailable, "91"},
+		{"/shed", http.StatusServiceUnavailable, "91"},
+		{"/forbidden", http.StatusForbidden, ""},
+	}
+
+	for _, tc := range tests {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, tc.path, nil))
+
+		if w.Code != tc.expectedCode {
+			t.Errorf("unexpected status code for %s: expected=%d, actual=%d", tc.path, tc.expectedCode, w.Code)
+		}
+		if got := w.Header().Get("Retry-After"); got != tc.expectedRetryAfter {
+			t.Errorf("unexpected Retry-After for %s: expected=%q, actual=%q", tc.path, tc.expectedRetryAfter, got)
+		}
+	}
+}
+
+func TestIndex(t *testing.T) {
+	tests := []struct {
+		name         string
+		method       string
+		path         string
+		expectedCode int
+		expectedBody string
+	}{
+		{"root", http.MethodGet, "/", http.StatusOK, "index"},
+		{"other path", http.MethodGet, "/anything", http.StatusNotFound, "404 page not found\n"},
+		{"HEAD", http.MethodHead, "/", http.StatusOK, ""},
+		{"POST", http.MethodPost, "/", http.StatusMethodNotAllowed, "Method not allowed\n"},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			router := NewRouter()
+			router.Index(func(w http.ResponseWriter, r *http.Request) {
+				w.Write([]byte("index")) // nolint: errcheck
+			}).Use(func(next http.Handler) http.Handler {
+				return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+					w.Header().Set("X-Index", "true")
+					next.ServeHTTP(w, r)
+				})
+			})
+
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, httptest.NewRequest(tc.method, tc.path, nil))
+
+			if w.Code != tc.expectedCode {
+				t.Errorf("unexpected status code: expected=%d, actual=%d", tc.expectedCode, w.Code)
+			}
+			if body := w.Body.String(); body != tc.expectedBody {
+				t.Errorf("unexpected response body: expected=%q, actual=%q", tc.expectedBody, body)
+			}
+			if expected := tc.expectedCode == http.StatusOK; (w.Header().Get("X-Index") == "true") != expected {
+				t.Errorf("unexpected route middleware: expected=%t, actual=%t", expected, !expected)
+			}
+		})
+	}
+}
+
+func TestWithRejectUnknownLength(t *testing.T) {
+	tests := []struct {
+		name          string
+		options       []RouterOption
+		contentLength int64
+		expectedCode  int
+	}{
+		{"chunked body rejected", []RouterOption{WithRejectUnknownLength()}, -1, http.StatusLengthRequired},
+		{"declared length accepted", []RouterOption{WithRejectUnknownLength()}, 7, http.StatusOK},
+		{"chunked body accepted without option", nil, -1, http.StatusOK},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			router := NewRouter(tc.options...)
+			router.HandleRoute(http.MethodPost, "/upload", func(w http.ResponseWriter, r *http.Request) {})
+
+			req := httptest.NewRequest(http.MethodPost, "/upload", strings.NewReader("payload"))
+			req.ContentLength = tc.contentLength
+			if tc.contentLength < 0 {
+				req.TransferEncoding = []string{"chunked"}
+			}
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			if w.Code != tc.expectedCode {
+				t.Errorf("unexpected status code: expected=%d, actual=%d", tc.expectedCode, w.Code)
+			}
+		})
+	}
+
+	// Requests without a body are not affected
+	router := NewRouter(WithRejectUnknownLength())
+	router.HandleRoute(http.MethodGet, "/", func(w http.ResponseWriter, r *http.Request) {})
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil))
+	if w.Code != http.StatusOK {
+		t.Errorf("unexpected status code for request without body: expected=%d, actual=%d", http.StatusOK, w.Code)
+	}
+}
+
+func TestWithAutoOptionsSubrouter(t *testing.T) {
+	router := NewRouter(WithAutoOptions())
+	router.HandleRoute(http.MethodGet, "/status", func(w http.ResponseWriter, r *http.Request) {})
+
+	api := router.Subrouter("/api")
+	for _, method := range []string{http.MethodGet, http.MethodPut, http.MethodDelete} {
+		api.HandleRoute(method, "/users/:id", func(w http.ResponseWriter, r *http.Request) {})
+	}
+	api.HandleRoute(http.MethodPost, "/users", func(w http.ResponseWriter, r *http.Request) {})
+
+	tests := []struct {
+		path          string
+		expectedCode  int
+		expectedAllow string
+	}{
+		{"/api/users/1", http.StatusNoContent, "DELETE, GET, OPTIONS, PUT"},
+		{"/api/users", http.StatusNoContent, "OPTIONS, POST"},
+		{"/status", http.StatusNoContent, "GET, OPTIONS"},
+		{"/api/unknown", http.StatusNotFound, ""},
+	}
+
+	for _, tc := range tests {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(http.MethodOptions, tc.path, nil))
+
+		if w.Code != tc.expectedCode {
+			t.Errorf("unexpected status code for %s: expected=%d, actual=%d", tc.path, tc.expectedCode, w.Code)
+		}
+		if allow := w.Header().Get("Allow"); allow != tc.expectedAllow {
+			t.Errorf("unexpected Allow header for %s: expected=%q, actual=%q", tc.path, tc.expectedAllow, allow)
+		}
+	}
+}
+
+func TestMethodNotAllowedHandler(t *testing.T) {
+	router := NewRouter()
+	router.HandleRoute(http.MethodGet, "/users/:id", func(w http.ResponseWriter, r *http.Request) {})
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodDelete, "/users/1", nil))
+	if w.Code != http.StatusMethodNotAllowed || w.Body.String() != "Method not allowed\n" {
+		t.Errorf("unexpected default response: code=%d, body=%q", w.Code, w.Body.String())
+	}
+
+	router.MethodNotAllowedHandler = func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		fmt.Fprintf(w, "%s is read-only, use %s", r.URL.Path, w.Header().Get("Allow")) // nolint: errcheck
+	}
+
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodDelete, "/users/1", nil))
+	if w.Code != http.StatusMethodNotAllowed {
+		t.Errorf("unexpected status code: expected=%d, actual=%d", http.StatusMethodNotAllowed, w.Code)
+	}
+	if expected := "/users/1 is read-only, use GET"; w.Body.String() != expected {
+		t.Errorf("unexpected response body: expected=%q, actual=%q", expected, w.Body.String())
+	}
+}
+
+func TestCloneConfig(t *testing.T) {
+	base := NewRouter(WithAutoOptions(), WithMaxRequestBodySize(16))
+	base.NotFoundHandler = func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "custom not found", http.StatusNotFound)
+	}
+	base.Use(func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("X-Base", "true")
+			next.ServeHTTP(w, r)
+		})
+	})
+	base.HandleRoute(http.MethodGet, "/base", func(w http.ResponseWriter, r *http.Request) {})
+
+	clone := base.CloneConfig()
+	clone.HandleRoute(http.MethodGet, "/clone", func(w http.ResponseWriter, r *http.Request) {})
+
+	serve := func(router *Router, method, path string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(method, path, nil))
+		return w
+	}
+
+	// Options, middleware and handlers are shared
+	if w := serve(clone, http.MethodGet, "/clone"); w.Code != http.StatusOK || w.Header().Get("X-Base") != "true" {
+		t.Errorf("expected clone route to run base middleware: code=%d, X-Base=%q", w.Code, w.Header().Get("X-Base"))
+	}
+	if w := serve(clone, http.MethodOptions, "/clone"); w.Code != http.StatusNoContent {
+		t.Errorf("expected clone to answer OPTIONS automatically, got %d", w.Code)
+	}
+	if clone.MaxRequestBodySize != 16 {
+		t.Errorf("unexpected MaxRequestBodySize: expected=%d, actual=%d", 16, clone.MaxRequestBodySize)
+	}
+
+	// Routes are not
+	if w := serve(clone, http.MethodGet, "/base"); w.Code != http.StatusNotFound || w.Body.String() != "custom not found\n" {
+		t.Errorf("expected base route to be missing from clone: code=%d, body=%q", w.Code, w.Body.String())
+	}
+	if w := serve(base, http.MethodGet, "/clone"); w.Code != http.StatusNotFound {
+		t.Errorf("expected clone route to be missing from base, got %d", w.Code)
+	}
+
+	// Middleware registered on the clone doesn't affect the original
+	clone.Use(func(next http.Handler) http.Handler { return next })
+	if len(base.middleware) != 1 {
+		t.Errorf("unexpected base middleware count: expected=%d, actual=%d", 1, len(base.middleware))
+	}
+}
+
+type teapotHandler struct{}
+
+func (teapotHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	w.WriteHeader(http.StatusTeapot)
+}
+
+func TestWithMethodNotAllowedHandler(t *testing.T) {
+	tests := []struct {
+		name    string
+		handler http.Handler
+	}{
+		{"plain handler", teapotHandler{}},
+		{"handler func", http.HandlerFunc(teapotHandler{}.ServeHTTP)},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			router := NewRouter(WithMethodNotAllowedHandler(tc.handler))
+			router.HandleRoute(http.MethodGet, "/users/:id", func(w http.ResponseWriter, r *http.Request) {})
+
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/users/1", nil))
+
+			if w.Code != http.StatusTeapot {
+				t.Errorf("unexpected status code: expected=%d, actual=%d", http.StatusTeapot, w.Code)
+			}
+		})
+	}
+}
+
+func TestHeadBodySuppression(t *testing.T) {
+	handler := func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/plain")
+		w.Header().Set("Content-Length", "11")
+		w.Header().Set("X-Custom", "kept")
+		w.WriteHeader(http.StatusAccepted)
+		w.Write([]byte("hello world")) // nolint: errcheck
+	}
+
+	tests := []struct {
+		name    string
+		options []RouterOption
+		method  string
+	}{
+		{"explicit HEAD route", nil, http.MethodHead},
+		{"HEAD synthesized from GET", []RouterOption{WithAutoHead()}, http.MethodGet},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			router := NewRouter(tc.options...)
+			router.HandleRoute(tc.method, "/resource", handler)
+
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, httptest.NewRequest(http.MethodHead, "/resource", nil))
+
+			if w.Code != http.StatusAccepted {
+				t.Errorf("unexpected status code: expected=%d, actual=%d", http.StatusAccepted, w.Code)
+			}
+			if w.Body.Len() != 0 {
+				t.Errorf("unexpected response body: %q", w.Body.String())
+			}
+			for header, expected := range map[string]string{"Content-Type": "text/plain", "Content-Length": "11", "X-Custom": "kept"} {
+				if got := w.Header().Get(header); got != expected {
+					t.Errorf("unexpected %s header: expected=%q, actual=%q", header, expected, got)
+				}
+			}
+		})
+	}
+}
+
+func TestWithAutoHead(t *testing.T) {
+	get := func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Length", "5")
+		w.Header().Set("X-Handler", "get")
+		w.Write([]byte("hello")) // nolint: errcheck
+	}
+	head := func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-Handler", "head")
+	}
+
+	tests := []struct {
+		name            string
+		options         []RouterOption
+		path            string
+		expectedStatus  int
+		expectedHandler string
+		expectedLength  string
+	}{
+		{"disabled", nil, "/get", http.StatusMethodNotAllowed, "", ""},
+		{"GET route serves HEAD", []RouterOption{WithAutoHead()}, "/get", http.StatusOK, "get", "5"},
+		{"explicit HEAD route wins", []RouterOption{WithAutoHead()}, "/both", http.StatusOK, "head", ""},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			router := NewRouter(tc.options...)
+			router.HandleRoute(http.MethodGet, "/get", get)
+			router.HandleRoute(http.MethodGet, "/both", get)
+			router.HandleRoute(http.MethodHead, "/both", head)
+
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, httptest.NewRequest(http.MethodHead, tc.path, nil))
+
+			if w.Code != tc.expectedStatus {
+				t.Errorf("unexpected status code: expected=%d, actual=%d", tc.expectedStatus, w.Code)
+			}
+			if got := w.Header().Get("X-Handler"); got != tc.expectedHandler {
+				t.Errorf("unexpected handler: expected=%q, actual=%q", tc.expectedHandler, got)
+			}
+			if got := w.Header().Get("Content-Length"); got != tc.expectedLength {
+				t.Errorf("unexpected Content-Length: expected=%q, actual=%q", tc.expectedLength, got)
+			}
+			if tc.expectedStatus == http.StatusOK && w.Body.Len() != 0 {
+				t.Errorf("unexpected response body: %q", w.Body.String())
+			}
+		})
+	}
+}
+
+func TestWithCaseInsensitive(t *testing.T) {
+	var params map[string]string
+	handler := func(w http.ResponseWriter, r *http.Request) {
+		params = Params(r)
+	}
+
+	tests := []struct {
+		name           string
+		options        []RouterOption
+		path           string
+		expectedStatus int
+		expectedID     string
+	}{
+		{"case-sensitive by default", nil, "/Users/AbC", http.StatusNotFound, ""},
+		{"literal segments ignore case", []RouterOption{WithCaseInsensitive()}, "/USERS/AbC", http.StatusOK, "AbC"},
+		{"static route ignores case", []RouterOption{WithCaseInsensitive()}, "/Health", http.StatusOK, ""},
+		{"exact case still matches", []RouterOption{WithCaseInsensitive()}, "/users/abc", http.StatusOK, "abc"},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			router := NewRouter(tc.options...)
+			router.HandleRoute(http.MethodGet, "/users/:id", handler)
+			router.HandleRoute(http.MethodGet, "/health", handler)
+
+			params = nil
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, tc.path, nil))
+
+			if w.Code != tc.expectedStatus {
+				t.Errorf("unexpected status code: expected=%d, actual=%d", tc.expectedStatus, w.Code)
+			}
+			if params["id"] != tc.expectedID {
+				t.Errorf("unexpected id param: expected=%q, actual=%q", tc.expectedID, params["id"])
+			}
+		})
+	}
+}
+
+func TestPanicHandler(t *testing.T) {
+	panicking := func(w http.ResponseWriter, r *http.Request) {
+		panic("danger danger danger!")
+	}
+
+	t.Run("default", func(t *testing.T) {
+		var logged bytes.Buffer
+		log.SetOutput(&logged)
+		defer log.SetOutput(os.Stderr)
+
+		router := NewRouter()
+		router.HandleRoute(http.MethodGet, "/panic", panicking)
+		router.Subrouter("/api").HandleRoute(http.MethodGet, "/panic", panicking)
+
+		for _, path := range []string{"/panic", "/api/panic"} {
+			logged.Reset()
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, path, nil))
+
+			if w.Code != http.StatusInternalServerError {
+				t.Errorf("unexpected status code for %s: expected=%d, actual=%d", path, http.StatusInternalServerError, w.Code)
+			}
+			if out := logged.String(); !strings.Contains(out, "danger danger danger!") || !strings.Contains(out, "goroutine") {
+				t.Errorf("expected the panic value and stack to be logged for %s, got %q", path, out)
+			}
+		}
+	})
+
+	t.Run("default classifies HTTPError panics", func(t *testing.T) {
+		router := NewRouter()
+		router.HandleRoute(http.MethodGet, "/admin", func(w http.ResponseWriter, r *http.Request) {
+			panic(HTTPError{Status: http.StatusForbidden, Message: "no access"})
+		})
+		recovering := NewRouter(WithRecovery(&panicLogger{}, false))
+		recovering.HandleRoute(http.MethodGet, "/admin", func(w http.ResponseWriter, r *http.Request) {
+			panic(HTTPError{Status: http.StatusForbidden, Message: "no access"})
+		})
+
+		for _, r := range []*Router{router, recovering} {
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/admin", nil))
+
+			if w.Code != http.StatusForbidden {
+				t.Errorf("unexpected status code: expected=%d, actual=%d", http.StatusForbidden, w.Code)
+			}
+			if expected := "no access\n"; w.Body.String() != expected {
+				t.Errorf("unexpected response body: expected=%q, actual=%q", expected, w.Body.String())
+			}
+		}
+	})
+
+	t.Run("precedence over recovery", func(t *testing.T) {
+		var recovered interface{}
+		logger := &panicLogger{}
+		router := NewRouter(
+			WithRecovery(logger, false),
+			WithPanicHandler(func(w http.ResponseWriter, r *http.Request, err interface{}) {
+				recovered = err
+				w.WriteHeader(http.StatusServiceUnavailable)
+			}),
+		)
+		router.HandleRoute(http.MethodGet, "/panic", panicking)
+
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/panic", nil))
+
+		if w.Code != http.StatusServiceUnavailable {
+			t.Errorf("unexpected status code: expected=%d, actual=%d", http.StatusServiceUnavailable, w.Code)
+		}
+		if recovered != "danger danger danger!" {
+			t.Errorf("unexpected panic value: %v", recovered)
+		}
+		if len(logger.logged) != 0 {
+			t.Errorf("expected recovery to be bypassed, got logged %v", logger.logged)
+		}
+	})
+
+	t.Run("custom", func(t *testing.T) {
+		var recovered interface{}
+		router := NewRouter()
+		router.PanicHandler = func(w http.ResponseWriter, r *http.Request, err interface{}) {
+			recovered = err
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+		router.HandleRoute(http.MethodGet, "/panic", panicking)
+		router.Subrouter("/api").HandleRoute(http.MethodGet, "/panic", panicking)
+
+		for _, path := range []string{"/panic", "/api/panic"} {
+			recovered = nil
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, path, nil))
+
+			if w.Code != http.StatusServiceUnavailable {
+				t.Errorf("unexpected status code for %s: expected=%d, actual=%d", path, http.StatusServiceUnavailable, w.Code)
+			}
+			if recovered != "danger danger danger!" {
+				t.Errorf("unexpected panic value for %s: %v", path, recovered)
+			}
+		}
+	})
+
+	t.Run("abort handler", func(t *testing.T) {
+		router := NewRouter()
+		router.HandleRoute(http.MethodGet, "/abort", func(w http.ResponseWriter, r *http.Request) {
+			panic(http.ErrAbortHandler)
+		})
+
+		defer func() {
+			if err := recover(); err != http.ErrAbortHandler {
+				t.Errorf("expected http.ErrAbortHandler to be re-raised, got %v", err)
+			}
+		}()
+		router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/abort", nil))
+	})
+}
+
+func TestWithPanicHandler(t *testing.T) {
+	var recovered interface{}
+	router := NewRouter(WithPanicHandler(func(w http.ResponseWriter, r *http.Request, err interface{}) {
+		recovered = err
+		http.Error(w, "recovered", http.StatusBadGateway)
+	}))
+	router.HandleRoute(http.MethodGet, "/panic", func(w http.ResponseWriter, r *http.Request) {
+		panic("danger danger danger!")
+	})
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/panic", nil))
+
+	if recovered != "danger danger danger!" {
+		t.Errorf("unexpected recovered value: %v", recovered)
+	}
+	if w.Code != http.StatusBadGateway {
+		t.Errorf("unexpected status code: expected=%d, actual=%d", http.StatusBadGateway, w.Code)
+	}
+	if w.Body.String() != "recovered\n" {
+		t.Errorf("unexpected response body: %q", w.Body.String())
+	}
+}
+
+func TestRouteUse(t *testing.T) {
+	tag := func(name string) func(http.Handler) http.Handler {
+		return func(next http.Handler) http.Handler {
+			return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Add("X-Chain", name)
+				next.ServeHTTP(w, r)
+			})
+		}
+	}
+
+	router := NewRouter()
+	router.Use(tag("global-1"), tag("global-2"))
+	router.HandleRoute(http.MethodGet, "/admin", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Add("X-Chain", "handler")
+	}).Use(tag("route-1")).Use(tag("route-2"))
+	router.HandleRoute(http.MethodGet, "/public", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Add("X-Chain", "handler")
+	})
+
+	tests := []struct {
+		path     string
+		expected []string
+	}{
+		{"/admin", []string{"global-1", "global-2", "route-1", "route-2", "handler"}},
+		{"/public", []string{"global-1", "global-2", "handler"}},
+	}
+
+	for _, tc := range tests {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, tc.path, nil))
+
+		if chain := w.Header().Values("X-Chain"); !reflect.DeepEqual(chain, tc.expected) {
+			t.Errorf("unexpected middleware order for %s: expected=%v, actual=%v", tc.path, tc.expected, chain)
+		}
+	}
+}
+
+func TestRouteSetters_ConcurrentWithServe(t *testing.T) {
+	router := NewRouter()
+	route := router.HandleRoute(http.MethodPost, "/items", func(w http.ResponseWriter, r *http.Request) {})
+	passthrough := func(next http.Handler) http.Handler { return next }
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for i := 0; i < 50; i++ {
+			route.Use(passthrough).
+				On("application/json", func(w http.ResponseWriter, r *http.Request) {}).
+				ValidateBody(func(body []byte) error { return nil }).
+				MaxConcurrent(100)
+		}
+	}()
+
+	for i := 0; i < 50; i++ {
+		req := httptest.NewRequest(http.MethodPost, "/items", strings.NewReader("{}"))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		if w.Code != http.StatusOK {
+			t.Fatalf("unexpected status code: expected=%d, actual=%d", http.StatusOK, w.Code)
+		}
+	}
+	<-done
+}
+
+func TestWithWildcardQuery(t *testing.T) {
+	router := NewRouter(WithWildcardQuery())
+
+	var captured string
+	capture := func(w http.ResponseWriter, r *http.Request) {
+		captured = router.Params(r)["path"]
+	}
+	router.HandleRoute(http.MethodGet, "/validate/*", capture)
+	router.HandleRoute(http.MethodGet, "/users/:path", capture)
+
+	tests := []struct {
+		requestPath string
+		expected    string
+	}{
+		{"/validate/foo?k=v", "foo?k=v"},
+		{"/validate/foo/bar?a=1&b=2", "foo/bar?a=1&b=2"},
+		{"/validate/foo", "foo"},
+		{"/users/42?k=v", "42"},
+	}
+
+	for _, tc := range tests {
+		captured = ""
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, tc.requestPath, nil))
+
+		if w.Code != http.StatusOK {
+			t.Errorf("unexpected status code for %s: expected=%d, actual=%d", tc.requestPath, http.StatusOK, w.Code)
+		}
+		if captured != tc.expected {
+			t.Errorf("unexpected capture for %s: expected=%q, actual=%q", tc.requestPath, tc.expected, captured)
+		}
+	}
+}
+
+func TestRouteOnMethodNotAllowed(t *testing.T) {
+	router := NewRouter()
+	router.MethodNotAllowedHandler = func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "global", http.StatusMethodNotAllowed)
+	}
+
+	ok := func(w http.ResponseWriter, r *http.Request) {}
+	router.HandleRoute(http.MethodGet, "/reports/:id", ok).
+		OnMethodNotAllowed(func(w http.ResponseWriter, r *http.Request) {
+			http.Error(w, "read-only, allowed: "+w.Header().Get("Allow"), http.StatusMethodNotAllowed)
+		})
+	router.HandleRoute(http.MethodHead, "/reports/:id", ok)
+	router.HandleRoute(http.MethodGet, "/users/:id", ok)
+
+	tests := []struct {
+		method       string
+		path         string
+		expectedCode int
+		expectedBody string
+	}{
+		{http.MethodDelete, "/reports/1", http.StatusMethodNotAllowed, "read-only, allowed: GET, HEAD\n"},
+		{http.MethodDelete, "/users/1", http.StatusMethodNotAllowed, "global\n"},
+		{http.MethodGet, "/reports/1", http.StatusOK, ""},
+	}
+
+	for _, tc := range tests {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(tc.method, tc.path, nil))
+
+		if w.Code != tc.expectedCode {
+			t.Errorf("unexpected status code for %s %s: expected=%d, actual=%d", tc.method, tc.path, tc.expectedCode, w.Code)
+		}
+		if w.Body.String() != tc.expectedBody {
+			t.Errorf("unexpected response body for %s %s: expected=%q, actual=%q", tc.method, tc.path, tc.expectedBody, w.Body.String())
+		}
+	}
+}
+
+func TestRouteGuard(t *testing.T) {
+	router := NewRouter()
+	respond := func(body string) http.HandlerFunc {
+		return func(w http.ResponseWriter, r *http.Request) {
+			w.Write([]byte(body)) // nolint: errcheck
+		}
+	}
+	version := func(v string) func(*http.Request) bool {
+		return func(r *http.Request) bool {
+			return r.Header.Get("X-Api-Version") == v
+		}
+	}
+
+	router.HandleRoute(http.MethodGet, "/reports", respond("v2")).Guard(version("2"))
+	router.HandleRoute(http.MethodGet, "/reports", respond("v1")).Guard(version("1"))
+	router.HandleRoute(http.MethodGet, "/users/:id", respond("beta user")).Guard(version("beta"))
+	router.HandleRoute(http.MethodGet, "/users/:id", respond("user"))
+
+	tests := []struct {
+		path         string
+		version      string
+		expectedCode int
+		expectedBody string
+	}{
+		{"/reports", "2", http.StatusOK, "v2"},
+		{"/reports", "1", http.StatusOK, "v1"},
+		{"/reports", "", http.StatusNotFound, "404 page not found\n"},
+		{"/users/42", "beta", http.StatusOK, "beta user"},
+		{"/users/42", "", http.StatusOK, "user"},
+	}
+
+	for _, tc := range tests {
+		req := httptest.NewRequest(http.MethodGet, tc.path, nil)
+		if tc.version != "" {
+			req.Header.Set("X-Api-Version", tc.version)
+		}
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		if w.Code != tc.expectedCode {
+			t.Errorf("unexpected status code for %s (version %q): expected=%d, actual=%d", tc.path, tc.version, tc.expectedCode, w.Code)
+		}
+		if w.Body.String() != tc.expectedBody {
+			t.Errorf("unexpected response body for %s (version %q): expected=%q, actual=%q", tc.path, tc.version, tc.expectedBody, w.Body.String())
+		}
+	}
+}
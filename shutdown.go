@@ -0,0 +1,68 @@
+package muxer
+
+import (
+	"context"
+	"net/http"
+	"sync/atomic"
+	"time"
+
+	"github.com/shellfu/muxer/middleware"
+)
+
+// drainLogInterval is how often Shutdown logs the number of requests still in flight.
+const drainLogInterval = time.Second
+
+// ActiveRequests returns the number of requests the router is currently serving,
+// including those dispatched to its subrouters.
+func (r *Router) ActiveRequests() int64 {
+	return atomic.LoadInt64(&r.active)
+}
+
+/*
+Shutdown gracefully shuts down srv, whose handler is the router: it stops accepting
+new connections and waits for the requests in flight to complete. While draining,
+the number of active requests is logged to logger, if not nil, every second. If
+they have not completed within timeout, the remaining connections are closed and
+context.DeadlineExceeded is returned.
+
+	go srv.ListenAndServe()
+	<-stop
+	if err := router.Shutdown(srv, 30*time.Second, log.Default()); err != nil {
+	    log.Println("forced shutdown:", err)
+	}
+*/
+func (r *Router) Shutdown(srv *http.Server, timeout time.Duration, logger middleware.RecoveryLogger) error {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	done := make(chan error, 1)
+	go func() {
+		done <- srv.Shutdown(ctx)
+	}()
+
+	ticker := time.NewTicker(drainLogInterval)
+	defer ticker.Stop()
+
+	r.logDraining(logger)
+	for {
+		select {
+		case err := <-done:
+			if err == context.DeadlineExceeded {
+				if logger != nil {
+					logger.Println("muxer: drain timed out, closing connections with", r.ActiveRequests(), "active requests")
+				}
+				srv.Close() // nolint: errcheck
+			}
+			return err
+		case <-ticker.C:
+			r.logDraining(logger)
+		}
+	}
+}
+
+// logDraining logs the number of active requests while shutting down.
+func (r *Router) logDraining(logger middleware.RecoveryLogger) {
+	if logger != nil {
+		logger.Println("muxer: draining,", r.ActiveRequests(), "active requests")
+	}
+}
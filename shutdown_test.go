@@ -0,0 +1,86 @@
+package muxer
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+type drainLogger struct {
+	mu    sync.Mutex
+	lines []string
+}
+
+func (l *drainLogger) Println(v ...interface{}) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.lines = append(l.lines, strings.TrimSuffix(fmt.Sprintln(v...), "\n"))
+}
+
+func TestActiveRequests(t *testing.T) {
+	router := NewRouter()
+
+	started := make(chan struct{}, 2)
+	release := make(chan struct{})
+	router.HandleRoute(http.MethodGet, "/block", func(w http.ResponseWriter, r *http.Request) {
+		started <- struct{}{}
+		<-release
+	})
+
+	var wg sync.WaitGroup
+	for i := 0; i < 2; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/block", nil))
+		}()
+	}
+	<-started
+	<-started
+
+	if active := router.ActiveRequests(); active != 2 {
+		t.Errorf("unexpected active requests: expected=%d, actual=%d", 2, active)
+	}
+
+	close(release)
+	wg.Wait()
+
+	if active := router.ActiveRequests(); active != 0 {
+		t.Errorf("unexpected active requests: expected=%d, actual=%d", 0, active)
+	}
+}
+
+func TestShutdown(t *testing.T) {
+	router := NewRouter()
+
+	started := make(chan struct{})
+	release := make(chan struct{})
+	router.HandleRoute(http.MethodGet, "/block", func(w http.ResponseWriter, r *http.Request) {
+		close(started)
+		<-release
+	})
+
+	server := httptest.NewServer(router)
+	defer server.Close()
+	defer close(release)
+
+	go http.Get(server.URL + "/block") // nolint: errcheck
+	<-started
+
+	logger := &drainLogger{}
+	err := router.Shutdown(server.Config, 50*time.Millisecond, logger)
+	if err != context.DeadlineExceeded {
+		t.Errorf("unexpected error: expected=%v, actual=%v", context.DeadlineExceeded, err)
+	}
+
+	logger.mu.Lock()
+	defer logger.mu.Unlock()
+	if len(logger.lines) == 0 || logger.lines[0] != "muxer: draining, 1 active requests" {
+		t.Errorf("unexpected log lines: %q", logger.lines)
+	}
+}
@@ -0,0 +1,238 @@
+package muxer
+
+import (
+	"fmt"
+	"html"
+	"io"
+	"mime"
+	"net/http"
+	"net/url"
+	"path"
+	"sort"
+	"strings"
+
+	"github.com/shellfu/muxer/middleware"
+)
+
+/*
+The staticConfig struct contains the settings of the Static and StaticFS handlers.
+Precompressed enables serving ".gz" siblings of requested files to clients that
+accept gzip. IndexFile names the file served for directory requests and DirListing
+enables listing directories without one.
+*/
+type staticConfig struct {
+	Precompressed bool
+	IndexFile     string
+	DirListing    bool
+}
+
+// StaticOption is a function that modifies the configuration of Static and StaticFS.
+type StaticOption func(*staticConfig)
+
+// WithPrecompressed makes the static handler serve a pre-compressed ".gz" sibling of
+// the requested file (e.g. app.js.gz for app.js), when it exists and the client
+// accepts gzip, instead of the file itself.
+func WithPrecompressed() StaticOption {
+	return func(cfg *staticConfig) {
+		cfg.Precompressed = true
+	}
+}
+
+// WithIndexFile makes the static handler serve the named file, e.g. "index.html",
+// from a requested directory instead of responding with 404.
+func WithIndexFile(name string) StaticOption {
+	return func(cfg *staticConfig) {
+		cfg.IndexFile = name
+	}
+}
+
+// WithDirListing makes the static handler respond to requests for directories
+// without an index file with an HTML listing of their entries instead of 404.
+func WithDirListing() StaticOption {
+	return func(cfg *staticConfig) {
+		cfg.DirListing = true
+	}
+}
+
+/*
+Static returns a handler serving files from the directory dir. It is a shorthand
+for StaticFS(http.Dir(dir), options...).
+
+	Example usage:
+	  router.HandleRoute(http.MethodGet, "/static/*", muxer.Static("./public", muxer.WithPrecompressed()))
+*/
+func Static(dir string, options ...StaticOption) http.HandlerFunc {
+	return StaticFS(http.Dir(dir), options...)
+}
+
+/*
+StaticFS returns a handler serving files from fsys. When registered on a wildcard
+route, the captured "path" parameter names the file to serve; otherwise the request
+path is used. Missing files result in a 404.
+
+Requests for directories are answered with the index file set by WithIndexFile if
+the directory contains it, else with a listing of the directory if WithDirListing
+is given, and with a 404 otherwise.
+
+With WithPrecompressed, a client that accepts gzip is served the ".gz" sibling of
+the requested file if it exists, with Content-Encoding set to gzip and the
+Content-Type derived from the original file's extension. This avoids compressing
+static assets on every request.
+
+	Example usage:
+	  //go:embed assets
+	  var assets embed.FS
+
+	  router.HandleRoute(http.MethodGet, "/assets/*", muxer.StaticFS(http.FS(assets)))
+*/
+func StaticFS(fsys http.FileSystem, options ...StaticOption) http.HandlerFunc {
+	cfg := &staticConfig{}
+	for _, option := range options {
+		option(cfg)
+	}
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		name, ok := Params(r)["path"]
+		if !ok {
+			name = r.URL.Path
+		}
+		name = path.Clean("/" + name)
+
+		if cfg.Precompressed {
+			w.Header().Add("Vary", "Accept-Encoding")
+			if middleware.AcceptsEncoding(r.Header.Values("Accept-Encoding"), "gzip") && servePrecompressed(w, r, fsys, name) {
+				return
+			}
+		}
+
+		if serveFile(w, r, fsys, name) {
+			return
+		}
+		if cfg.IndexFile != "" && serveFile(w, r, fsys, path.Join(name, cfg.IndexFile)) {
+			return
+		}
+		if cfg.DirListing && serveDirListing(w, r, fsys, name) {
+			return
+		}
+		http.NotFound(w, r)
+	}
+}
+
+/*
+SPAHandler returns a handler suitable for use as the NotFoundHandler of a router
+serving a single-page application from dir.
+
+For unmatched GET and HEAD requests it serves the requested file if it exists.
+Otherwise, paths that look like assets (those with a file extension) get a real
+404, while extensionless paths are treated as client-side routes and receive
+dir/index.html. Other methods always get a 404.
+
+	Example usage:
+	  router := muxer.NewRouter(muxer.WithNotFoundHandler(muxer.SPAHandler("./dist")))
+	  router.HandleRoute(http.MethodGet, "/api/users/:id", getUser)
+*/
+func SPAHandler(dir string) http.HandlerFunc {
+	root := http.Dir(dir)
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet && r.Method != http.MethodHead {
+			http.NotFound(w, r)
+			return
+		}
+
+		name := path.Clean("/" + r.URL.Path)
+		if serveFile(w, r, root, name) {
+			return
+		}
+
+		if path.Ext(name) != "" || !serveFile(w, r, root, "/index.html") {
+			http.NotFound(w, r)
+		}
+	}
+}
+
+// serveFile serves the named file from fsys and reports whether it was served.
+// Missing files and directories are not served.
+func serveFile(w http.ResponseWriter, r *http.Request, fsys http.FileSystem, name string) bool {
+	f, err := fsys.Open(name)
+	if err != nil {
+		return false
+	}
+	defer f.Close()
+
+	info, err := f.Stat()
+	if err != nil || info.IsDir() {
+		return false
+	}
+
+	http.ServeContent(w, r, info.Name(), info.ModTime(), f)
+	return true
+}
+
+// serveDirListing writes an HTML listing of the named directory in fsys and reports
+// whether it was served. Files that are not directories are not served.
+func serveDirListing(w http.ResponseWriter, r *http.Request, fsys http.FileSystem, name string) bool {
+	f, err := fsys.Open(name)
+	if err != nil {
+		return false
+	}
+	defer f.Close()
+
+	info, err := f.Stat()
+	if err != nil || !info.IsDir() {
+		return false
+	}
+
+	entries, err := f.Readdir(-1)
+	if err != nil {
+		return false
+	}
+	sort.Slice(entries, func(i, j int) bool { return entries[i].Name() < entries[j].Name() })
+
+	// Links are relative, so they need the directory name unless the request path
+	// already ends with a slash.
+	base := ""
+	if !strings.HasSuffix(r.URL.Path, "/") {
+		base = path.Base(name) + "/"
+	}
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	var b strings.Builder
+	b.WriteString("<!doctype html>\n<pre>\n")
+	for _, entry := range entries {
+		entryName := entry.Name()
+		if entry.IsDir() {
+			entryName += "/"
+		}
+		href := (&url.URL{Path: base + entryName}).String()
+		fmt.Fprintf(&b, "<a href=\"%s\">%s</a>\n", html.EscapeString(href), html.EscapeString(entryName))
+	}
+	b.WriteString("</pre>\n")
+	io.WriteString(w, b.String()) // nolint: errcheck
+	return true
+}
+
+// servePrecompressed serves the ".gz" sibling of the named file, if one exists,
+// and reports whether it was served.
+func servePrecompressed(w http.ResponseWriter, r *http.Request, fsys http.FileSystem, name string) bool {
+	f, err := fsys.Open(name + ".gz")
+	if err != nil {
+		return false
+	}
+	defer f.Close()
+
+	info, err := f.Stat()
+	if err != nil || info.IsDir() {
+		return false
+	}
+
+	contentType := mime.TypeByExtension(path.Ext(name))
+	if contentType == "" {
+		contentType = "application/octet-stream"
+	}
+	w.Header().Set("Content-Type", contentType)
+	w.Header().Set("Content-Encoding", "gzip")
+
+	http.ServeContent(w, r, name, info.ModTime(), f)
+	return true
+}
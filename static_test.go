@@ -0,0 +1,171 @@
+package muxer
+
+import (
+	"mime"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// writeFiles creates the given files, relative to dir, with their contents.
+func writeFiles(t *testing.T, dir string, files map[string]string) {
+	t.Helper()
+	for name, content := range files {
+		fullPath := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(fullPath), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(fullPath, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestSPAHandler(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		"index.html":    "<html>app</html>",
+		"assets/app.js": "console.log('app')",
+	})
+
+	router := NewRouter(WithNotFoundHandler(SPAHandler(dir)))
+	router.HandleRoute(http.MethodGet, "/api/users/:id", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("user")) // nolint: errcheck
+	})
+
+	tests := []struct {
+		name         string
+		path         string
+		expectedCode int
+		expectedBody string
+	}{
+		{"existing asset", "/assets/app.js", http.StatusOK, "console.log('app')"},
+		{"client route serves index.html", "/dashboard/settings", http.StatusOK, "<html>app</html>"},
+		{"missing asset", "/assets/missing.js", http.StatusNotFound, "404 page not found\n"},
+		{"registered route", "/api/users/1", http.StatusOK, "user"},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, tc.path, nil)
+			w := httptest.NewRecorder()
+
+			router.ServeHTTP(w, req)
+
+			if w.Code != tc.expectedCode {
+				t.Errorf("unexpected status code: expected=%d, actual=%d", tc.expectedCode, w.Code)
+			}
+			if w.Body.String() != tc.expectedBody {
+				t.Errorf("unexpected response body: expected=%q, actual=%q", tc.expectedBody, w.Body.String())
+			}
+		})
+	}
+}
+
+func TestStatic_Precompressed(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		"app.js":    "console.log('plain')",
+		"app.js.gz": "gzipped-bytes",
+		"style.css": "body {}",
+	})
+
+	router := NewRouter()
+	router.HandleRoute(http.MethodGet, "/static/*", Static(dir, WithPrecompressed()))
+
+	jsType := mime.TypeByExtension(".js")
+
+	tests := []struct {
+		name                string
+		path                string
+		acceptEncoding      string
+		expectedCode        int
+		expectedBody        string
+		expectedEncoding    string
+		expectedContentType string
+	}{
+		{"gzip-accepting client gets .gz sibling", "/static/app.js", "gzip, deflate", http.StatusOK, "gzipped-bytes", "gzip", jsType},
+		{"client without gzip gets plain file", "/static/app.js", "", http.StatusOK, "console.log('plain')", "", jsType},
+		{"gzip explicitly refused", "/static/app.js", "gzip;q=0", http.StatusOK, "console.log('plain')", "", jsType},
+		{"wildcard accepts gzip", "/static/app.js", "*", http.StatusOK, "gzipped-bytes", "gzip", jsType},
+		{"identity preferred over gzip", "/static/app.js", "identity, gzip;q=0.5", http.StatusOK, "console.log('plain')", "", jsType},
+		{"no .gz sibling", "/static/style.css", "gzip", http.StatusOK, "body {}", "", mime.TypeByExtension(".css")},
+		{"missing file", "/static/missing.js", "gzip", http.StatusNotFound, "404 page not found\n", "", "text/plain; charset=utf-8"},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, tc.path, nil)
+			if tc.acceptEncoding != "" {
+				req.Header.Set("Accept-Encoding", tc.acceptEncoding)
+			}
+			w := httptest.NewRecorder()
+
+			router.ServeHTTP(w, req)
+
+			if w.Code != tc.expectedCode {
+				t.Errorf("unexpected status code: expected=%d, actual=%d", tc.expectedCode, w.Code)
+			}
+			if w.Body.String() != tc.expectedBody {
+				t.Errorf("unexpected response body: expected=%q, actual=%q", tc.expectedBody, w.Body.String())
+			}
+			if got := w.Header().Get("Content-Encoding"); got != tc.expectedEncoding {
+				t.Errorf("unexpected Content-Encoding: expected=%q, actual=%q", tc.expectedEncoding, got)
+			}
+			if got := w.Header().Get("Content-Type"); got != tc.expectedContentType {
+				t.Errorf("unexpected Content-Type: expected=%q, actual=%q", tc.expectedContentType, got)
+			}
+		})
+	}
+}
+
+func TestStatic_Directories(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		"docs/index.html": "<h1>docs</h1>",
+		"files/a.txt":     "a",
+		"files/sub/b.txt": "b",
+		"files/<odd>.txt": "odd",
+	})
+
+	tests := []struct {
+		name         string
+		options      []StaticOption
+		path         string
+		expectedCode int
+		expectedBody string
+	}{
+		{"index file served", []StaticOption{WithIndexFile("index.html")}, "/static/docs", http.StatusOK, "<h1>docs</h1>"},
+		{"index file served with trailing slash", []StaticOption{WithIndexFile("index.html")}, "/static/docs/", http.StatusOK, "<h1>docs</h1>"},
+		{"no index and listing disabled", []StaticOption{WithIndexFile("index.html")}, "/static/files", http.StatusNotFound, "404 page not found\n"},
+		{"directory without options", nil, "/static/docs", http.StatusNotFound, "404 page not found\n"},
+		{
+			"listing enabled", []StaticOption{WithIndexFile("index.html"), WithDirListing()}, "/static/files", http.StatusOK,
+			"<!doctype html>\n<pre>\n<a href=\"files/%3Codd%3E.txt\">&lt;odd&gt;.txt</a>\n<a href=\"files/a.txt\">a.txt</a>\n<a href=\"files/sub/\">sub/</a>\n</pre>\n",
+		},
+		{
+			"listing with trailing slash", []StaticOption{WithDirListing()}, "/static/files/sub/", http.StatusOK,
+			"<!doctype html>\n<pre>\n<a href=\"b.txt\">b.txt</a>\n</pre>\n",
+		},
+		{"index preferred over listing", []StaticOption{WithIndexFile("index.html"), WithDirListing()}, "/static/docs", http.StatusOK, "<h1>docs</h1>"},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			router := NewRouter()
+			router.HandleRoute(http.MethodGet, "/static/*", Static(dir, tc.options...))
+
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, tc.path, nil))
+
+			if w.Code != tc.expectedCode {
+				t.Errorf("unexpected status code: expected=%d, actual=%d", tc.expectedCode, w.Code)
+			}
+			if w.Body.String() != tc.expectedBody {
+				t.Errorf("unexpected response body: expected=%q, actual=%q", tc.expectedBody, w.Body.String())
+			}
+		})
+	}
+}
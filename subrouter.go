@@ -0,0 +1,98 @@
+package muxer
+
+import (
+	"net"
+	"net/http"
+	"regexp"
+	"strings"
+)
+
+// subrouterParamRegex matches the parameters of a subrouter attribute value. Names
+// must start with a letter so host ports such as ":8080" are not parameters.
+var subrouterParamRegex = regexp.MustCompile(`:([A-Za-z_][\w-]*)`)
+
+// subrouterPattern matches requests against a subrouter attribute value containing
+// parameters, either a host such as ":tenant.example.com" or a path prefix such as
+// "/orgs/:org".
+type subrouterPattern struct {
+	re     *regexp.Regexp
+	params []string
+	isHost bool
+}
+
+// compileSubrouterPattern compiles attrValue into a subrouterPattern, or returns nil
+// if it contains no parameters.
+func compileSubrouterPattern(attrValue string) *subrouterPattern {
+	if !subrouterParamRegex.MatchString(attrValue) {
+		return nil
+	}
+
+	pattern := &subrouterPattern{isHost: !strings.HasPrefix(attrValue, "/")}
+
+	// A host parameter matches one label, a path parameter one segment
+	segment := `([^/]+)`
+	if pattern.isHost {
+		segment = `([^.]+)`
+	}
+
+	var expr strings.Builder
+	last := 0
+	for _, loc := range subrouterParamRegex.FindAllStringSubmatchIndex(attrValue, -1) {
+		expr.WriteString(regexp.QuoteMeta(attrValue[last:loc[0]]))
+		expr.WriteString(segment)
+		pattern.params = append(pattern.params, attrValue[loc[2]:loc[3]])
+		last = loc[1]
+	}
+	expr.WriteString(regexp.QuoteMeta(attrValue[last:]))
+
+	if pattern.isHost {
+		pattern.re = regexp.MustCompile("(?i)^" + expr.String() + "$")
+	} else {
+		pattern.re = regexp.MustCompile("^" + expr.String())
+	}
+	return pattern
+}
+
+// match reports whether the request matches the pattern and returns the captured
+// parameters. Hosts are taken from the request URL, falling back to the Host header,
+// without the port. A matched path prefix is trimmed from the request path.
+func (p *subrouterPattern) match(req *http.Request) (map[string]string, bool) {
+	subject := req.URL.Path
+	if p.isHost {
+		subject = req.URL.Host
+		if subject == "" {
+			subject = req.Host
+		}
+		if host, _, err := net.SplitHostPort(subject); err == nil {
+			subject = host
+		}
+	}
+
+	match := p.re.FindStringSubmatch(subject)
+	if match == nil {
+		return nil, false
+	}
+
+	params := make(map[string]string, len(p.params))
+	for i, name := range p.params {
+		params[name] = match[i+1]
+	}
+	if !p.isHost {
+		req.URL.Path = strings.TrimPrefix(req.URL.Path, match[0])
+	}
+	return params, true
+}
+
+// mergeParams adds the parameters of outer that are not in params to params and
+// returns it. Parameters of the innermost match take precedence.
+func mergeParams(params, outer map[string]string) map[string]string {
+	if params == nil {
+		params = make(map[string]string, len(outer))
+	}
+	for name, value := range outer {
+		if _, ok := params[name]; !ok {
+			params[name] = value
+		}
+	}
+	return params
+}
@@ -0,0 +1,73 @@
+package muxer
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestSubrouterParams(t *testing.T) {
+	router := NewRouter()
+
+	var params map[string]string
+	handler := func(w http.ResponseWriter, r *http.Request) {
+		params = Params(r)
+	}
+
+	tenants := router.Subrouter(":tenant.example.com")
+	tenants.HandleRoute(http.MethodGet, "/users/:id", handler)
+	tenants.Subrouter("/projects/:project").HandleRoute(http.MethodGet, "/tasks/:id", handler)
+	router.Subrouter("/orgs/:org").HandleRoute(http.MethodGet, "/repos/:repo", handler)
+
+	tests := []struct {
+		name           string
+		host           string
+		path           string
+		expectedCode   int
+		expectedParams map[string]string
+	}{
+		{"wildcard host", "acme.example.com", "/users/42", http.StatusOK, map[string]string{"tenant": "acme", "id": "42"}},
+		{"wildcard host with port", "acme.example.com:8443", "/users/42", http.StatusOK, map[string]string{"tenant": "acme", "id": "42"}},
+		{"nested prefix params", "acme.example.com", "/projects/apollo/tasks/7", http.StatusOK, map[string]string{"tenant": "acme", "project": "apollo", "id": "7"}},
+		{"path prefix params", "localhost", "/orgs/shellfu/repos/muxer", http.StatusOK, map[string]string{"org": "shellfu", "repo": "muxer"}},
+		{"host does not match", "example.com", "/users/42", http.StatusNotFound, nil},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			params = nil
+			req := httptest.NewRequest(http.MethodGet, tc.path, nil)
+			req.Host = tc.host
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			if w.Code != tc.expectedCode {
+				t.Errorf("unexpected status code: expected=%d, actual=%d", tc.expectedCode, w.Code)
+			}
+			if len(params) != len(tc.expectedParams) {
+				t.Errorf("unexpected params: expected=%v, actual=%v", tc.expectedParams, params)
+			}
+			for name, value := range tc.expectedParams {
+				if params[name] != value {
+					t.Errorf("unexpected param %q: expected=%q, actual=%q", name, value, params[name])
+				}
+			}
+		})
+	}
+}
+
+func TestSubrouterParamsPrecedence(t *testing.T) {
+	router := NewRouter()
+
+	var id string
+	router.Subrouter("/accounts/:id").HandleRoute(http.MethodGet, "/items/:id", func(w http.ResponseWriter, r *http.Request) {
+		id = Params(r)["id"]
+	})
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/accounts/1/items/2", nil))
+
+	if id != "2" {
+		t.Errorf("unexpected id param: expected=%q, actual=%q", "2", id)
+	}
+}
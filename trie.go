@@ -0,0 +1,213 @@
+package muxer
+
+import (
+	"strings"
+	"unicode/utf8"
+)
+
+/*
+routeNode is a node of the tree routes are indexed in by path segment, so matching
+a request only considers the routes whose leading segments fit its path, and the
+time it takes depends on the depth of the path rather than the number of routes.
+
+Routes whose template consists of literal segments and single-segment parameters
+are matched by the tree alone. Routes with segments the tree cannot represent, such
+as greedy parameters, wildcards or parameters embedded in a literal, are indexed
+by their leading segments and confirmed with their regular expression.
+*/
+type routeNode struct {
+	literal map[string]*routeNode
+	param   *routeNode
+
+	// routes end at this node and are matched by their segments alone
+	routes []*Route
+	// partial routes continue with segments the tree cannot represent
+	partial []*Route
+}
+
+// treeSegment is a segment of a route template as indexed in the tree.
+type treeSegment struct {
+	literal string
+	param   bool
+}
+
+// insert indexes route in the tree rooted at n. Literal segments are lowercased if
+// fold is true.
+func (n *routeNode) insert(route *Route, fold bool) {
+	segments, exact := templateSegments(route.template, fold)
+
+	node := n
+	for i, segment := range segments {
+		if segment.param {
+			if node.param == nil {
+				node.param = &routeNode{}
+			}
+			node = node.param
+			if exact {
+				route.paramSegments = append(route.paramSegments, i)
+			}
+			continue
+		}
+
+		child := node.literal[segment.literal]
+		if child == nil {
+			if node.literal == nil {
+				node.literal = make(map[string]*routeNode)
+			}
+			child = &routeNode{}
+			node.literal[segment.literal] = child
+		}
+		node = child
+	}
+
+	route.node = node
+	route.exact = exact
+	if exact {
+		node.routes = append(node.routes, route)
+	} else {
+		node.partial = append(node.partial, route)
+	}
+}
+
+// remove removes route from the node it was indexed at.
+func (n *routeNode) remove(route *Route) {
+	node := route.node
+	if node == nil {
+		return
+	}
+	if route.exact {
+		node.routes = removeRoute(node.routes, route)
+	} else {
+		node.partial = removeRoute(node.partial, route)
+	}
+	route.node = nil
+}
+
+// removeRoute returns a copy of routes without route.
+func removeRoute(routes []*Route, route *Route) []*Route {
+	remaining := make([]*Route, 0, len(routes))
+	for _, r := range routes {
+		if r != route {
+			remaining = append(remaining, r)
+		}
+	}
+	return remaining
+}
+
+/*
+visit calls fn for every route indexed under n that may match the remainder rest of
+a request path, whose segments before rest have led to n. exact tells fn that the
+route matches the path; otherwise its regular expression must confirm the match.
+end reports that the path has no segments left.
+*/
+func (n *routeNode) visit(rest string, end, fold bool, fn func(route *Route, exact bool)) {
+	for _, route := range n.partial {
+		fn(route, false)
+	}
+	if end {
+		for _, route := range n.routes {
+			fn(route, true)
+		}
+		return
+	}
+
+	segment, next, last := rest, "", true
+	if i := strings.IndexByte(rest, '/'); i >= 0 {
+		segment, next, last = rest[:i], rest[i+1:], false
+	}
+
+	key := segment
+	if fold {
+		key = strings.ToLower(segment)
+	}
+	if child := n.literal[key]; child != nil {
+		child.visit(next, last, fold, fn)
+	}
+	if n.param != nil && isParamValue(segment) {
+		n.param.visit(next, last, fold, fn)
+	}
+}
+
+/*
+templateSegments returns the segments template is indexed by. exact reports
+whether they describe the whole template; otherwise they are the leading segments
+up to the first one the tree cannot represent.
+*/
+func templateSegments(template string, fold bool) (segments []treeSegment, exact bool) {
+	wildcard := strings.Contains(template, "*")
+	if wildcard {
+		// Wildcard routes match their base path followed by anything
+		template = strings.TrimSuffix(strings.TrimSuffix(template, "*"), "/")
+	}
+
+	for _, segment := range strings.Split(template, "/") {
+		switch {
+		case isPlainLiteral(segment):
+			if fold {
+				segment = strings.ToLower(segment)
+			}
+			segments = append(segments, treeSegment{literal: segment})
+		case !wildcard && isParamSegment(segment):
+			segments = append(segments, treeSegment{param: true})
+		default:
+			return segments, false
+		}
+	}
+	return segments, !wildcard
+}
+
+// isPlainLiteral reports whether segment is an ASCII literal without parameters or
+// characters with a meaning in regular expressions, so it matches only itself.
+func isPlainLiteral(segment string) bool {
+	for i := 0; i < len(segment); i++ {
+		if segment[i] >= utf8.RuneSelf || strings.IndexByte(`\.+*?()|[]{}^$:`, segment[i]) >= 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// isParamSegment reports whether segment consists of a single non-greedy parameter.
+func isParamSegment(segment string) bool {
+	return templateParamRegex.FindString(segment) == segment && !strings.HasSuffix(segment, greedyModifier)
+}
+
+// isParamValue reports whether segment can be the value of a single-segment
+// parameter, which consists of word characters, hyphens and dots.
+func isParamValue(segment string) bool {
+	if segment == "" {
+		return false
+	}
+	for i := 0; i < len(segment); i++ {
+		c := segment[i]
+		if !('a' <= c && c <= 'z' || 'A' <= c && c <= 'Z' || '0' <= c && c <= '9' || c == '_' || c == '-' || c == '.') {
+			return false
+		}
+	}
+	return true
+}
+
+// segmentParams returns the parameters of a route matched by the tree alone,
+// taken from the segments of path.
+func (r *Route) segmentParams(path string) map[string]string {
+	params := make(map[string]string, len(r.params))
+	if len(r.params) == 0 {
+		return params
+	}
+
+	index, next := 0, 0
+	for segment := 0; next < len(r.paramSegments); segment++ {
+		end := strings.IndexByte(path[index:], '/')
+		if end < 0 {
+			end = len(path)
+		} else {
+			end += index
+		}
+		if segment == r.paramSegments[next] {
+			params[r.params[next]] = path[index:end]
+			next++
+		}
+		index = end + 1
+	}
+	return params
+}
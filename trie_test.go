@@ -0,0 +1,61 @@
+package muxer
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestRouteTree(t *testing.T) {
+	var matched string
+	tag := func(name string) http.HandlerFunc {
+		return func(w http.ResponseWriter, r *http.Request) {
+			matched = name
+			for _, key := range CurrentRoute(r).Params() {
+				matched += " " + key + "=" + Params(r)[key]
+			}
+		}
+	}
+
+	router := NewRouter()
+	router.HandleRoute(http.MethodGet, "/users/:id", tag("user"))
+	router.HandleRoute(http.MethodGet, "/users/me", tag("me"))
+	router.HandleRoute(http.MethodGet, "/files/:name.json", tag("json"))
+	router.HandleRoute(http.MethodGet, "/proxy/:target{greedy}/info", tag("proxy"))
+	router.HandleRoute(http.MethodGet, "/static/*", tag("static"))
+	router.HandleRoute(http.MethodGet, "/:section/latest", tag("latest"))
+	router.HandleRoute(http.MethodGet, "/news/:id", tag("news"))
+
+	tests := []struct {
+		path     string
+		expected string
+	}{
+		// Static routes are looked up first, other routes in registration order
+		{"/users/me", "me"},
+		{"/users/42", "user id=42"},
+		{"/files/report.json", "json name=report"},
+		{"/proxy/a/b/c/info", "proxy target=a/b/c"},
+		{"/static/css/site.css", "static path=css/site.css"},
+		{"/news/latest", "latest section=news"},
+		{"/news/7", "news id=7"},
+		{"/users/a b", ""},
+		{"/users/42/extra", ""},
+		{"/users", ""},
+	}
+
+	for _, tc := range tests {
+		matched = ""
+		router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "http://example.com"+strings.ReplaceAll(tc.path, " ", "%20"), nil))
+		if matched != tc.expected {
+			t.Errorf("unexpected match for %s: expected=%q, actual=%q", tc.path, tc.expected, matched)
+		}
+	}
+
+	router.Unregister(http.MethodGet, "/users/me")
+	matched = ""
+	router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/users/me", nil))
+	if matched != "user id=me" {
+		t.Errorf("unexpected match after unregister: expected=%q, actual=%q", "user id=me", matched)
+	}
+}
@@ -0,0 +1,201 @@
+package muxer
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+/*
+URL builds the path of the route named name with Route.Name, substituting its
+parameters with the values in params. Values are escaped; those of greedy and
+wildcard parameters may contain slashes, which are kept. The wildcard of a
+template like "/static/*" takes its value from the "path" parameter, as in
+Params. It returns an error if no route has the name, or one listing every
+parameter of the route that params lacks.
+
+	router.HandleRoute(http.MethodGet, "/users/:id/posts/:slug", showPost).Name("post")
+	url, err := router.URL("post", map[string]string{"id": "42", "slug": "hello"})
+	// url == "/users/42/posts/hello"
+*/
+func (r *Router) URL(name string, params map[string]string) (string, error) {
+	r.mu.RLock()
+	route := r.namedRoutes[name]
+	r.mu.RUnlock()
+
+	if route == nil {
+		return "", fmt.Errorf("muxer: no route named %q", name)
+	}
+
+	var missing []string
+	for _, param := range route.params {
+		if _, ok := params[param]; !ok {
+			missing = append(missing, strconv.Quote(param))
+		}
+	}
+	if len(missing) == 1 {
+		return "", fmt.Errorf("muxer: missing parameter %s for route %q", missing[0], name)
+	}
+	if len(missing) > 1 {
+		return "", fmt.Errorf("muxer: missing parameters %s for route %q", strings.Join(missing, ", "), name)
+	}
+
+	if strings.Contains(route.template, "*") {
+		base := strings.TrimSuffix(strings.TrimSuffix(route.template, "*"), "/")
+		return base + "/" + escapeSegments(params["path"]), nil
+	}
+
+	path := templateParamRegex.ReplaceAllStringFunc(route.template, func(m string) string {
+		value := params[strings.TrimSuffix(m[1:], greedyModifier)]
+		if strings.HasSuffix(m, greedyModifier) {
+			return escapeSegments(value)
+		}
+		return url.PathEscape(value)
+	})
+	return path, nil
+}
+
+// escapeSegments escapes each slash-separated segment of value.
+func escapeSegments(value string) string {
+	segments := strings.Split(value, "/")
+	for i, segment := range segments {
+		segments[i] = url.PathEscape(segment)
+	}
+	return strings.Join(segments, "/")
+}
+
+/*
+ExternalURL reconstructs the absolute, client-facing URL of the request. It is
+useful for building redirect and pagination links behind TLS-terminating proxies.
+
+Inside a subrouter the path includes the prefix the subrouter stripped, so the
+URL is the one the client requested. The scheme is https when the request arrived
+over TLS and http otherwise, and the host is taken from the Host header. When the
+immediate peer (r.RemoteAddr) is one of trustedProxies, the last values of the
+X-Forwarded-Proto and X-Forwarded-Host headers, which that proxy appended, override
+the scheme and host; earlier values may come from the client and are ignored.
+Forwarded headers from any other peer are ignored. Entries in trustedProxies may be
+single IP addresses or CIDR ranges.
+
+	Example usage:
+	  u := muxer.ExternalURL(r, []string{"10.0.0.0/8"})
+	  muxer.SetPaginationLinks(w, u, page, perPage, total)
+*/
+func ExternalURL(r *http.Request, trustedProxies []string) *url.URL {
+	u := &url.URL{
+		Scheme:   "http",
+		Host:     r.Host,
+		Path:     r.URL.Path,
+		RawPath:  r.URL.RawPath,
+		RawQuery: r.URL.RawQuery,
+	}
+	if r.TLS != nil {
+		u.Scheme = "https"
+	}
+	// Inside a subrouter the request path lacks the prefix the subrouter stripped
+	if stripped := strippedPath(r); stripped != "" {
+		u.Path, u.RawPath = stripped+r.URL.Path, ""
+	}
+	if u.Host == "" {
+		u.Host = r.URL.Host
+	}
+
+	if !isTrustedProxy(r.RemoteAddr, trustedProxies) {
+		return u
+	}
+
+	if proto := strings.ToLower(lastHeaderValue(r.Header, "X-Forwarded-Proto")); proto == "http" || proto == "https" {
+		u.Scheme = proto
+	}
+	if host := lastHeaderValue(r.Header, "X-Forwarded-Host"); host != "" {
+		u.Host = host
+	}
+
+	return u
+}
+
+/*
+Redirect replies to the request with a redirect to to, resolved against the request
+URL into an absolute URL, so relative targets such as "../login" or "next" work as
+they would in a browser. Inside a subrouter the request URL includes the path prefix
+the subrouter stripped. For targets on the same host the query of the request is
+preserved, with the parameters given in to replacing those of the same name.
+Targets on other hosts are used as they are. If to cannot be parsed, Redirect
+replies with 500 Internal Server Error.
+
+	Example usage:
+	  // GET /docs/v1/intro?lang=en
+	  muxer.Redirect(w, r, "../v2/intro?theme=dark", http.StatusFound)
+	  // Location: http://example.com/docs/v2/intro?lang=en&theme=dark
+*/
+func Redirect(w http.ResponseWriter, r *http.Request, to string, code int) {
+	target, err := url.Parse(to)
+	if err != nil {
+		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		return
+	}
+
+	base := ExternalURL(r, nil)
+	resolved := base.ResolveReference(target)
+	if strings.EqualFold(resolved.Host, base.Host) {
+		query := r.URL.Query()
+		for key, values := range target.Query() {
+			query[key] = values
+		}
+		resolved.RawQuery = query.Encode()
+	}
+
+	http.Redirect(w, r, resolved.String(), code)
+}
+
+// strippedPath returns the part of the request path stripped by the subrouters
+// the request was routed through, or an empty string at the top level.
+func strippedPath(r *http.Request) string {
+	stripped, _ := r.Context().Value(strippedPathKey).(string)
+	return stripped
+}
+
+// isTrustedProxy reports whether the IP of remoteAddr matches one of the trusted
+// IP addresses or CIDR ranges.
+func isTrustedProxy(remoteAddr string, trustedProxies []string) bool {
+	host, _, err := net.SplitHostPort(remoteAddr)
+	if err != nil {
+		host = remoteAddr
+	}
+	ip := net.ParseIP(host)
+	if ip == nil {
+		return false
+	}
+
+	for _, proxy := range trustedProxies {
+		if strings.Contains(proxy, "/") {
+			if _, network, err := net.ParseCIDR(proxy); err == nil && network.Contains(ip) {
+				return true
+			}
+			continue
+		}
+		if trusted := net.ParseIP(proxy); trusted != nil && trusted.Equal(ip) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// lastHeaderValue returns the last comma-separated value of the named header, as
+// appended by the trusted proxy the request came from. Values before it were
+// forwarded from further upstream and may have been set by the client.
+func lastHeaderValue(h http.Header, name string) string {
+	values := h.Values(name)
+	if len(values) == 0 {
+		return ""
+	}
+	value := values[len(values)-1]
+	if i := strings.LastIndexByte(value, ','); i >= 0 {
+		value = value[i+1:]
+	}
+	return strings.TrimSpace(value)
+}
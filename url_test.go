@@ -0,0 +1,228 @@
+package muxer
+
+import (
+	"crypto/tls"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestExternalURL(t *testing.T) {
+	trusted := []string{"10.0.0.0/8", "192.168.1.10"}
+
+	tests := []struct {
+		name       string
+		target     string
+		remoteAddr string
+		tls        bool
+		headers    map[string]string
+		expected   string
+	}{
+		{
+			name:       "direct request",
+			target:     "http://api.example.com/users?page=2",
+			remoteAddr: "203.0.113.5:51234",
+			expected:   "http://api.example.com/users?page=2",
+		},
+		{
+			name:       "direct TLS request",
+			target:     "https://api.example.com/users",
+			remoteAddr: "203.0.113.5:51234",
+			tls:        true,
+			expected:   "https://api.example.com/users",
+		},
+		{
+			name:       "trusted proxy by CIDR",
+			target:     "http://internal:8080/users?page=2",
+			remoteAddr: "10.1.2.3:40000",
+			headers: map[string]string{
+				"X-Forwarded-Proto": "https",
+				"X-Forwarded-Host":  "api.example.com",
+			},
+			expected: "https://api.example.com/users?page=2",
+		},
+		{
+			name:       "trusted proxy by IP appends to forged values",
+			target:     "http://internal:8080/users",
+			remoteAddr: "192.168.1.10:40000",
+			headers: map[string]string{
+				"X-Forwarded-Proto": "http, https",
+				"X-Forwarded-Host":  "evil.example.com, api.example.com",
+			},
+			expected: "https://api.example.com/users",
+		},
+		{
+			name:       "untrusted proxy headers are ignored",
+			target:     "http://internal:8080/users",
+			remoteAddr: "203.0.113.5:51234",
+			headers: map[string]string{
+				"X-Forwarded-Proto": "https",
+				"X-Forwarded-Host":  "evil.example.com",
+			},
+			expected: "http://internal:8080/users",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, tc.target, nil)
+			req.RemoteAddr = tc.remoteAddr
+			if tc.tls {
+				req.TLS = &tls.ConnectionState{}
+			} else {
+				req.TLS = nil
+			}
+			for k, v := range tc.headers {
+				req.Header.Set(k, v)
+			}
+
+			if got := ExternalURL(req, trusted).String(); got != tc.expected {
+				t.Errorf("expected URL %q, got %q", tc.expected, got)
+			}
+		})
+	}
+}
+
+func TestExternalURL_Subrouter(t *testing.T) {
+	var got string
+	router := NewRouter()
+	router.Subrouter("/api").HandleRoute(http.MethodGet, "/self", func(w http.ResponseWriter, r *http.Request) {
+		got = ExternalURL(r, nil).String()
+	})
+
+	router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "http://example.com/api/self?x=1", nil))
+
+	if expected := "http://example.com/api/self?x=1"; got != expected {
+		t.Errorf("expected URL %q, got %q", expected, got)
+	}
+}
+
+func TestRedirect(t *testing.T) {
+	tests := []struct {
+		name     string
+		target   string
+		to       string
+		code     int
+		expected string
+	}{
+		{"relative path", "http://example.com/docs/v1/intro", "../v2/intro", http.StatusFound, "http://example.com/docs/v2/intro"},
+		{"sibling path", "http://example.com/docs/v1/intro", "setup", http.StatusSeeOther, "http://example.com/docs/v1/setup"},
+		{"absolute path preserves query", "http://example.com/old?page=2&sort=name", "/new", http.StatusMovedPermanently, "http://example.com/new?page=2&sort=name"},
+		{"target query overrides", "http://example.com/old?page=2&sort=name", "/new?page=1", http.StatusFound, "http://example.com/new?page=1&sort=name"},
+		{"other host untouched", "http://example.com/old?page=2", "https://other.example.org/login", http.StatusFound, "https://other.example.org/login"},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			Redirect(w, httptest.NewRequest(http.MethodGet, tc.target, nil), tc.to, tc.code)
+
+			if w.Code != tc.code {
+				t.Errorf("unexpected status code: expected=%d, actual=%d", tc.code, w.Code)
+			}
+			if location := w.Header().Get("Location"); location != tc.expected {
+				t.Errorf("unexpected Location: expected=%q, actual=%q", tc.expected, location)
+			}
+		})
+	}
+}
+
+func TestRedirect_Subrouter(t *testing.T) {
+	router := NewRouter()
+	redirect := func(w http.ResponseWriter, r *http.Request) {
+		Redirect(w, r, "../v2/intro", http.StatusFound)
+	}
+	router.Subrouter("/api").HandleRoute(http.MethodGet, "/docs/v1/intro", redirect)
+	router.Subrouter("/tenants/:tenant").HandleRoute(http.MethodGet, "/docs/v1/intro", redirect)
+
+	tests := []struct {
+		target   string
+		expected string
+	}{
+		{"http://example.com/api/docs/v1/intro?lang=en", "http://example.com/api/docs/v2/intro?lang=en"},
+		{"http://example.com/tenants/acme/docs/v1/intro", "http://example.com/tenants/acme/docs/v2/intro"},
+	}
+
+	for _, tc := range tests {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, tc.target, nil))
+
+		if w.Code != http.StatusFound {
+			t.Errorf("unexpected status code for %s: expected=%d, actual=%d", tc.target, http.StatusFound, w.Code)
+		}
+		if location := w.Header().Get("Location"); location != tc.expected {
+			t.Errorf("unexpected Location for %s: expected=%q, actual=%q", tc.target, tc.expected, location)
+		}
+	}
+}
+
+func TestRouterURL(t *testing.T) {
+	handler := func(w http.ResponseWriter, r *http.Request) {}
+
+	router := NewRouter()
+	router.HandleRoute(http.MethodGet, "/users/:id/posts/:slug", handler).Name("post")
+	router.HandleRoute(http.MethodGet, "/proxy/:target{greedy}/info", handler).Name("proxy")
+	router.HandleRoute(http.MethodGet, "/static/*", handler).Name("static")
+	router.HandleRoute(http.MethodGet, "/about", handler).Name("about")
+
+	tests := []struct {
+		name        string
+		route       string
+		params      map[string]string
+		expected    string
+		expectError bool
+	}{
+		{"params substituted", "post", map[string]string{"id": "42", "slug": "hello world"}, "/users/42/posts/hello%20world", false},
+		{"greedy param keeps slashes", "proxy", map[string]string{"target": "a/b/c"}, "/proxy/a/b/c/info", false},
+		{"wildcard", "static", map[string]string{"path": "css/site.css"}, "/static/css/site.css", false},
+		{"static route", "about", nil, "/about", false},
+		{"missing param", "post", map[string]string{"id": "42"}, "", true},
+		{"unknown name", "missing", nil, "", true},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			url, err := router.URL(tc.route, tc.params)
+			if tc.expectError {
+				if err == nil {
+					t.Errorf("expected error, got URL %q", url)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if url != tc.expected {
+				t.Errorf("unexpected URL: expected=%q, actual=%q", tc.expected, url)
+			}
+		})
+	}
+
+	router.Unregister(http.MethodGet, "/about")
+	if _, err := router.URL("about", nil); err == nil {
+		t.Error("expected error for unregistered named route")
+	}
+}
+
+func TestRouterURL_MissingParams(t *testing.T) {
+	router := NewRouter()
+	router.HandleRoute(http.MethodGet, "/a/:x/b/:y", func(w http.ResponseWriter, r *http.Request) {}).Name("ab")
+
+	tests := []struct {
+		params        map[string]string
+		expectedError string
+	}{
+		{map[string]string{"x": "1"}, `muxer: missing parameter "y" for route "ab"`},
+		{nil, `muxer: missing parameters "x", "y" for route "ab"`},
+	}
+
+	for _, tc := range tests {
+		url, err := router.URL("ab", tc.params)
+		if err == nil || err.Error() != tc.expectedError {
+			t.Errorf("unexpected error for %v: expected=%q, actual=%v", tc.params, tc.expectedError, err)
+		}
+		if url != "" {
+			t.Errorf("unexpected URL for %v: %q", tc.params, url)
+		}
+	}
+}